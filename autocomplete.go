@@ -17,9 +17,9 @@ func (ui *UI) commandLineCompleteDone(cmdLine string, index int, source int) boo
 		ui.cmdRegistry.history = append(ui.cmdRegistry.history, cmdLine)
 		ui.cmdRegistry.histIndex = len(ui.cmdRegistry.history)
 		ui.mainPage.commandLine.SetText(cmdLine)
-		err := ui.execCommand(cmdLine)
+		err := ui.execCommandChainSilent(cmdLine)
 		if err != nil {
-			ui.mainPage.appStatusText.SetText(err.Error())
+			ui.mainPage.setStatus("StatusError", err.Error())
 			return false
 		}
 		ui.app.SetFocus(ui.mainPage.focusedList)
@@ -64,7 +64,7 @@ func (ui *UI) commandLineComplete(currentText string) []string {
 		if namepart == "" {
 			return nil
 		}
-		possibleCmds := ui.cmdRegistry.matchPossibleCommands(namepart)
+		possibleCmds := ui.cmdRegistry.findCommand(namepart)
 		if len(possibleCmds) == 1 {
 			if possibleCmds[0].Complete != nil {
 				return possibleCmds[0].Complete(ui, fields[1], bang)
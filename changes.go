@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+)
+
+const (
+	maxStreamChanges = 500
+	defaultNewMark   = 5 * time.Minute
+)
+
+type ChangeKind string
+
+const (
+	changeLive    ChangeKind = "live"
+	changeOffline ChangeKind = "offline"
+	changeTitle   ChangeKind = "title"
+	changeGame    ChangeKind = "game"
+)
+
+// A difference for one stream between two fetches
+type StreamChange struct {
+	Time    time.Time
+	Kind    ChangeKind
+	Service string
+	Name    string
+	Old     string
+	New     string
+}
+
+// Changes between successive fetches and when each live stream first showed
+// up, keyed by streamKey
+type ChangeLog struct {
+	entries   []StreamChange
+	firstSeen map[string]time.Time
+	fetched   bool
+}
+
+func NewChangeLog() *ChangeLog {
+	return &ChangeLog{firstSeen: make(map[string]time.Time)}
+}
+
+// What is compared between fetches for each stream
+type streamSnapshot struct {
+	service string
+	name    string
+	title   string
+	game    string
+}
+
+func snapshotStreams(streams *ls.Streams) map[string]streamSnapshot {
+	snapshots := make(map[string]streamSnapshot)
+	for i := range streams.Twitch.Data {
+		stream := &streams.Twitch.Data[i]
+		snapshots[streamKey(stream)] = streamSnapshot{stream.GetService(), stream.UserName, stream.Title, stream.GameName}
+	}
+	for i := range streams.Strims.Data {
+		stream := &streams.Strims.Data[i]
+		snapshots[streamKey(stream)] = streamSnapshot{stream.Service, stream.Channel, stream.Title, ""}
+	}
+	return snapshots
+}
+
+// Diff {streams} against the {previous} fetch and remember the changes. The
+// first fetch only records which streams are live
+func (c *ChangeLog) record(previous, streams *ls.Streams, at time.Time) []StreamChange {
+	current := snapshotStreams(streams)
+	if !c.fetched {
+		c.fetched = true
+		for key := range current {
+			c.firstSeen[key] = time.Time{}
+		}
+		return nil
+	}
+	old := snapshotStreams(previous)
+	var changes []StreamChange
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		cur := current[key]
+		prev, ok := old[key]
+		if !ok {
+			changes = append(changes, StreamChange{at, changeLive, cur.service, cur.name, "", cur.title})
+			c.firstSeen[key] = at
+			continue
+		}
+		if prev.title != cur.title {
+			changes = append(changes, StreamChange{at, changeTitle, cur.service, cur.name, prev.title, cur.title})
+		}
+		if prev.game != cur.game {
+			changes = append(changes, StreamChange{at, changeGame, cur.service, cur.name, prev.game, cur.game})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := current[key]; !ok {
+			prev := old[key]
+			changes = append(changes, StreamChange{at, changeOffline, prev.service, prev.name, prev.title, ""})
+			delete(c.firstSeen, key)
+		}
+	}
+	c.entries = append(c.entries, changes...)
+	if len(c.entries) > maxStreamChanges {
+		c.entries = c.entries[len(c.entries)-maxStreamChanges:]
+	}
+	return changes
+}
+
+// Whether {data} went live within {window}
+func (c *ChangeLog) isNew(data ls.StreamData, window time.Duration) bool {
+	seen, ok := c.firstSeen[streamKey(data)]
+	return ok && !seen.IsZero() && time.Since(seen) < window
+}
+
+// Time until the first of the marks of isNew goes away, 0 when none is shown
+func (c *ChangeLog) untilMarkExpires(now time.Time, window time.Duration) time.Duration {
+	var until time.Duration
+	for _, seen := range c.firstSeen {
+		if seen.IsZero() {
+			continue
+		}
+		if d := seen.Add(window).Sub(now); d > 0 && (until == 0 || d < until) {
+			until = d
+		}
+	}
+	return until
+}
+
+// Summary such as "+5 live, -3 offline, 2 titles", "" without changes
+func summarizeChanges(changes []StreamChange) string {
+	counts := make(map[ChangeKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	var parts []string
+	if n := counts[changeLive]; n > 0 {
+		parts = append(parts, fmt.Sprintf("+%d live", n))
+	}
+	if n := counts[changeOffline]; n > 0 {
+		parts = append(parts, fmt.Sprintf("-%d offline", n))
+	}
+	if n := counts[changeTitle]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d titles", n))
+	}
+	if n := counts[changeGame]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d games", n))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (m *MainPage) describeChanges() []byte {
+	var lines []byte
+	for _, change := range slices.Backward(m.changeLog.entries) {
+		name := tview.Escape(change.Service + "/" + change.Name)
+		var detail string
+		switch change.Kind {
+		case changeLive:
+			name = m.hlText("StatusOk", "+ "+name)
+			detail = tview.Escape(change.New)
+		case changeOffline:
+			name = m.hlText("InfoDim", "- "+name)
+		case changeTitle, changeGame:
+			name = "~ " + name
+			detail = fmt.Sprintf(
+				"%s: %s -> %s",
+				change.Kind,
+				m.hlText("InfoDim", tview.Escape(change.Old)),
+				tview.Escape(change.New),
+			)
+		}
+		lines = fmt.Appendf(lines, "%s %s\n", m.hlText("InfoLabel", change.Time.Format(time.TimeOnly)), name)
+		if detail != "" {
+			lines = fmt.Appendf(lines, "    %s\n", detail)
+		}
+	}
+	return lines
+}
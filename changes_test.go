@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+)
+
+func changeTestStreams(twitch []ls.TwitchStreamData, strims []ls.StrimsStreamData) *ls.Streams {
+	return &ls.Streams{
+		Twitch: &ls.TwitchStreams{Data: twitch},
+		Strims: &ls.StrimsStreams{Data: strims},
+	}
+}
+
+func TestChangeLogRecord(t *testing.T) {
+	start := time.Now().Add(-2 * time.Minute)
+	first := changeTestStreams(
+		[]ls.TwitchStreamData{
+			{UserName: "alpha", Title: "a", GameName: "Chess"},
+			{UserName: "beta", Title: "b", GameName: "Go"},
+		},
+		[]ls.StrimsStreamData{{Channel: "gamma", Service: "youtube", Title: "c"}},
+	)
+	second := changeTestStreams(
+		[]ls.TwitchStreamData{
+			{UserName: "alpha", Title: "a2", GameName: "Chess"},
+			{UserName: "delta", Title: "d", GameName: "Go"},
+		},
+		[]ls.StrimsStreamData{{Channel: "gamma", Service: "youtube", Title: "c"}},
+	)
+	second.Twitch.Data[0].GameName = "Shogi"
+
+	c := NewChangeLog()
+	if changes := c.record(nil, first, start); changes != nil {
+		t.Fatalf("first fetch gave %v, want no changes", changes)
+	}
+	if c.isNew(&first.Twitch.Data[0], time.Hour) {
+		t.Error("a stream from the first fetch is marked as new")
+	}
+
+	at := start.Add(time.Minute)
+	changes := c.record(first, second, at)
+	want := []StreamChange{
+		{at, changeTitle, "twitch", "alpha", "a", "a2"},
+		{at, changeGame, "twitch", "alpha", "Chess", "Shogi"},
+		{at, changeLive, "twitch", "delta", "", "d"},
+		{at, changeOffline, "twitch", "beta", "b", ""},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %v, want %v", changes, want)
+	}
+	if !reflect.DeepEqual(c.entries, want) {
+		t.Errorf("entries are %v, want %v", c.entries, want)
+	}
+	if !c.isNew(&second.Twitch.Data[1], time.Hour) {
+		t.Error("the stream that went live is not marked as new")
+	}
+	if c.isNew(&second.Twitch.Data[1], 0) {
+		t.Error("the stream that went live is marked with newmark=0")
+	}
+	if _, ok := c.firstSeen["twitch/beta"]; ok {
+		t.Error("the stream that went offline is still remembered")
+	}
+	if got := c.untilMarkExpires(at, 5*time.Minute); got != 5*time.Minute {
+		t.Errorf("the new mark expires in %v, want 5m0s", got)
+	}
+	if got := c.untilMarkExpires(at.Add(5*time.Minute), 5*time.Minute); got != 0 {
+		t.Errorf("an expired mark is still scheduled in %v", got)
+	}
+}
+
+func TestChangeLogRecordLimit(t *testing.T) {
+	c := NewChangeLog()
+	streams := changeTestStreams([]ls.TwitchStreamData{{UserName: "alpha"}}, nil)
+	c.record(nil, streams, time.Now())
+	for i := 0; i <= maxStreamChanges; i++ {
+		next := changeTestStreams([]ls.TwitchStreamData{{UserName: "alpha", Title: string(rune('a' + i%2))}}, nil)
+		c.record(streams, next, time.Now())
+		streams = next
+	}
+	if len(c.entries) != maxStreamChanges {
+		t.Errorf("kept %d changes, want %d", len(c.entries), maxStreamChanges)
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	tests := []struct {
+		kinds []ChangeKind
+		want  string
+	}{
+		{nil, ""},
+		{[]ChangeKind{changeLive}, "+1 live"},
+		{[]ChangeKind{changeOffline, changeOffline}, "-2 offline"},
+		{[]ChangeKind{changeGame, changeTitle, changeLive, changeOffline, changeLive}, "+2 live, -1 offline, 1 titles, 1 games"},
+	}
+	for _, tt := range tests {
+		var changes []StreamChange
+		for _, kind := range tt.kinds {
+			changes = append(changes, StreamChange{Kind: kind})
+		}
+		if got := summarizeChanges(changes); got != tt.want {
+			t.Errorf("summarizeChanges(%v) = %q, want %q", tt.kinds, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Commands for the clipboard backends that read the text from stdin
+var clipboardCommands = map[string][]string{
+	"wl-copy": {"wl-copy"},
+	"xclip":   {"xclip", "-selection", "clipboard"},
+	"xsel":    {"xsel", "--clipboard", "--input"},
+}
+
+// Pick a backend based on the environment, falling back to OSC 52 which
+// works over SSH as long as the terminal supports it
+func detectClipboard() string {
+	hasCommand := func(name string) bool {
+		_, err := exec.LookPath(name)
+		return err == nil
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" && hasCommand("wl-copy") {
+		return "wl-copy"
+	}
+	if os.Getenv("DISPLAY") != "" {
+		if hasCommand("xclip") {
+			return "xclip"
+		}
+		if hasCommand("xsel") {
+			return "xsel"
+		}
+	}
+	return "osc52"
+}
+
+// Copy {text} using the backend from {setting}, which is auto, one of the
+// known backends, or a custom command that reads the text from stdin.
+// Returns the name of the backend that was used
+func copyToClipboard(setting string, text string) (string, error) {
+	backend := setting
+	if backend == "" || backend == "auto" {
+		backend = detectClipboard()
+	}
+	if backend == "osc52" {
+		return backend, copyOSC52(text)
+	}
+	var cmd *exec.Cmd
+	if args, ok := clipboardCommands[backend]; ok {
+		cmd = exec.Command(args[0], args[1:]...)
+	} else {
+		cmd = exec.Command("sh", "-c", backend)
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return backend, cmd.Run()
+}
+
+// Set the clipboard with the OSC 52 escape sequence, wrapped for tmux so
+// that it is passed through to the outer terminal
+func copyOSC52(text string) error {
+	seq := "\033]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\033Ptmux;" + strings.ReplaceAll(seq, "\033", "\033\033") + "\033\\"
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return errors.New("osc52: no terminal to write to")
+	}
+	defer tty.Close()
+	_, err = tty.WriteString(seq)
+	return err
+}
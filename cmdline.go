@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Stands in for the cursor while reading the text around it
+const cursorMarker = '\uE000'
+
+// Vim style command line editing, returns the event for the input field to
+// handle or nil if it was handled here
+func (ui *UI) editCommandLine(event *tcell.EventKey) *tcell.EventKey {
+	if pending := ui.cmdlinePending; pending != 0 {
+		ui.cmdlinePending = 0
+		switch pending {
+		case tcell.KeyCtrlR:
+			ui.insertRegister(event)
+		case tcell.KeyCtrlV:
+			ui.insertLiteral(event)
+		}
+		return nil
+	}
+	switch event.Key() {
+	case tcell.KeyCtrlR, tcell.KeyCtrlV:
+		ui.cmdlinePending = event.Key()
+		return nil
+	case tcell.KeyCtrlW:
+		before, _ := ui.splitCommandLine()
+		ui.feedCommandLine(repeatKey(tcell.KeyBackspace2, wordBeforeLen(before))...)
+		return nil
+	case tcell.KeyCtrlB:
+		return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+	case tcell.KeyCtrlE:
+		return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+	}
+	return event
+}
+
+// <C-r>{reg} inserts a register, <C-r><C-w> the name of the stream under the
+// cursor
+func (ui *UI) insertRegister(event *tcell.EventKey) {
+	var text string
+	switch {
+	case event.Key() == tcell.KeyCtrlW:
+		data, err := ui.getSelectedStreamData()
+		if err != nil {
+			ui.mainPage.setStatus("StatusWarning", err.Error())
+			return
+		}
+		text = data.GetName()
+	case event.Key() == tcell.KeyRune:
+		var err error
+		text, err = ui.registerContents(event.Rune())
+		if err != nil {
+			ui.mainPage.setStatus("StatusWarning", err.Error())
+			return
+		}
+	default:
+		return
+	}
+	ui.insertCommandLine(text)
+}
+
+// <C-v>{key} inserts {key} instead of running it, special keys as their
+// notation for use in mappings
+func (ui *UI) insertLiteral(event *tcell.EventKey) {
+	if event.Key() == tcell.KeyRune {
+		ui.insertCommandLine(string(event.Rune()))
+		return
+	}
+	ui.insertCommandLine(encodeMappingKey(event))
+}
+
+// Text of register {reg}, {a-z} hold recorded keys, : the last command line
+// and / the last search
+func (ui *UI) registerContents(reg rune) (string, error) {
+	switch {
+	case reg == ':':
+		history := ui.cmdRegistry.history
+		if len(history) == 0 {
+			return "", nil
+		}
+		return history[len(history)-1], nil
+	case reg == '/':
+		return ui.mainPage.lastSearch, nil
+	case isMacroRegister(reg):
+		return ui.macroRegistry.registers[strings.ToLower(string(reg))], nil
+	}
+	return "", fmt.Errorf("invalid register: %c", reg)
+}
+
+// Run {events} through the command line as if they came from a mapping, so
+// they skip the remaps above and are not recorded
+func (ui *UI) feedCommandLine(events ...*tcell.EventKey) {
+	handler := ui.mainPage.commandLine.InputHandler()
+	setFocus := func(p tview.Primitive) { ui.app.SetFocus(p) }
+	for _, event := range events {
+		ui.mapDepth++
+		handler(event, setFocus)
+	}
+}
+
+// Paste {text} at the cursor of the command line in one go
+func (ui *UI) pasteCommandLine(text string) {
+	cl := ui.mainPage.commandLine
+	// Pastes are refused while the completions are open, close them the way
+	// a mapping does
+	ui.mapDepth++
+	cl.Autocomplete()
+	ui.mapDepth--
+	cl.PasteHandler()(text, func(p tview.Primitive) { ui.app.SetFocus(p) })
+}
+
+// Insert {text} at the cursor of the command line
+func (ui *UI) insertCommandLine(text string) {
+	ui.pasteCommandLine(text)
+	ui.mainPage.commandLine.Autocomplete()
+}
+
+// Text of the command line before and after the cursor, found by pasting a
+// marker at the cursor and taking it back out. The changed func does not see
+// the marker
+func (ui *UI) splitCommandLine() (before, after string) {
+	cl := ui.mainPage.commandLine
+	cl.SetChangedFunc(nil)
+	defer cl.SetChangedFunc(ui.onTypeCommandChain)
+	ui.pasteCommandLine(string(cursorMarker))
+	before, after, _ = strings.Cut(cl.GetText(), string(cursorMarker))
+	ui.feedCommandLine(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone))
+	cl.Autocomplete()
+	return before, after
+}
+
+func repeatKey(key tcell.Key, count int) []*tcell.EventKey {
+	events := make([]*tcell.EventKey, count)
+	for i := range events {
+		events[i] = tcell.NewEventKey(key, 0, tcell.ModNone)
+	}
+	return events
+}
+
+func isKeywordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Number of runes <C-w> deletes from the end of {before}: the blanks, then
+// the keyword or the run of other characters before them, like vim
+func wordBeforeLen(before string) int {
+	runes := []rune(before)
+	i := len(runes)
+	for i > 0 && unicode.IsSpace(runes[i-1]) {
+		i--
+	}
+	if i > 0 {
+		keyword := isKeywordRune(runes[i-1])
+		for i > 0 && !unicode.IsSpace(runes[i-1]) && isKeywordRune(runes[i-1]) == keyword {
+			i--
+		}
+	}
+	return len(runes) - i
+}
+
+// Go to the previous (or next when {forward}) history entry that starts with
+// the text typed before browsing the history
+func (ui *UI) browseHistory(forward bool) {
+	r := ui.cmdRegistry
+	cl := ui.mainPage.commandLine
+	text := cl.GetText()
+	if r.histIndex >= len(r.history) || text != r.histText {
+		// Typed or edited since the last history entry was shown
+		r.histIndex = len(r.history)
+		r.histPrefix = text
+	}
+	step := -1
+	if forward {
+		step = 1
+	}
+	for i := r.histIndex + step; i >= 0 && i < len(r.history); i += step {
+		if strings.HasPrefix(r.history[i], r.histPrefix) {
+			r.histIndex = i
+			r.histText = r.history[i]
+			cl.SetText(r.histText)
+			cl.Autocomplete()
+			return
+		}
+	}
+	if forward {
+		// Past the newest entry, back to what was typed
+		r.histIndex = len(r.history)
+		r.histText = r.histPrefix
+		cl.SetText(r.histPrefix)
+		cl.Autocomplete()
+	}
+}
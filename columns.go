@@ -0,0 +1,290 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+)
+
+const defaultColumns = "name:20,viewers:7,game:24,service:8,uptime:6,title"
+
+// A column of the table list mode, with the value it shows for each kind of
+// stream. Lists without a value for the column leave it out
+type columnDef struct {
+	twitch func(m *MainPage, s *ls.TwitchStreamData) any
+	strims func(m *MainPage, s *ls.StrimsStreamData) any
+}
+
+var listColumns = map[string]columnDef{
+	"afk": {
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.AfkRustlers },
+	},
+	"channel": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return s.UserName },
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Channel },
+	},
+	"game": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return s.GameName },
+	},
+	"language": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return s.Language },
+	},
+	"live": {
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Live },
+	},
+	"name": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return s.UserName },
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Channel },
+	},
+	"nsfw": {
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Nsfw },
+	},
+	"rustlers": {
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Rustlers },
+	},
+	"service": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return s.GetService() },
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Service },
+	},
+	"title": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return s.Title },
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Title },
+	},
+	"trend": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return m.viewerHistory.trend(s) },
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return m.viewerHistory.trend(s) },
+	},
+	"uptime": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return time.Since(s.StartedAt) },
+	},
+	"viewers": {
+		twitch: func(m *MainPage, s *ls.TwitchStreamData) any { return s.ViewerCount },
+		strims: func(m *MainPage, s *ls.StrimsStreamData) any { return s.Rustlers },
+	},
+}
+
+func hasTwitchColumn(def columnDef) bool { return def.twitch != nil }
+func hasStrimsColumn(def columnDef) bool { return def.strims != nil }
+
+// A column to show and its width in cells, 0 leaves it unpadded
+type ListColumn struct {
+	name  string
+	width int
+}
+
+func parseColumns(spec string) ([]ListColumn, error) {
+	var columns []ListColumn
+	for field := range strings.SplitSeq(spec, ",") {
+		name, widthStr, hasWidth := strings.Cut(field, ":")
+		if _, ok := listColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %s", name)
+		}
+		col := ListColumn{name: name}
+		if hasWidth {
+			width, err := strconv.Atoi(widthStr)
+			if err != nil || width < 0 {
+				return nil, fmt.Errorf("invalid width for column %s: %s", name, widthStr)
+			}
+			col.width = width
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("no columns given")
+	}
+	return columns, nil
+}
+
+func formatColumns(columns []ListColumn) string {
+	fields := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if col.width == 0 {
+			fields = append(fields, col.name)
+		} else {
+			fields = append(fields, fmt.Sprintf("%s:%d", col.name, col.width))
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// Column and direction of a sortby value, "-" in front sorts descending
+func parseSortBy(spec string) (string, bool, error) {
+	name, desc := strings.CutPrefix(spec, "-")
+	if _, ok := listColumns[name]; !ok && name != "" {
+		return "", false, fmt.Errorf("unknown column %s", name)
+	}
+	return name, desc, nil
+}
+
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case string:
+		return removeVariationSelectors(strings.ReplaceAll(v, "\n", " "))
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case time.Duration:
+		return fmt.Sprintf("%dh%02d", int(v.Hours()), int(v.Minutes())%60)
+	case ViewerTrend:
+		return v.spark
+	}
+	return fmt.Sprint(value)
+}
+
+func compareCells(a, b any) int {
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(strings.ToLower(av), strings.ToLower(b.(string)))
+	case int:
+		return cmp.Compare(av, b.(int))
+	case bool:
+		if av == b.(bool) {
+			return 0
+		} else if av {
+			return 1
+		}
+		return -1
+	case time.Duration:
+		return cmp.Compare(av, b.(time.Duration))
+	case ViewerTrend:
+		return cmp.Compare(av.delta, b.(ViewerTrend).delta)
+	}
+	return 0
+}
+
+// Truncate or pad {text} to exactly {width} cells
+func fitCell(text string, width int) string {
+	if width == 0 {
+		return text
+	}
+	var fitted strings.Builder
+	used := 0
+	state := -1
+	rest := text
+	for rest != "" {
+		var cluster string
+		var w int
+		cluster, rest, w, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if used+w > width {
+			break
+		}
+		fitted.WriteString(cluster)
+		used += w
+	}
+	return fitted.String() + strings.Repeat(" ", width-used)
+}
+
+// Cells of one row of the table list mode, {cell} returns the value of a
+// column for the stream of the row
+func (m *MainPage) tableRow(marker string, cell func(def columnDef) (any, bool)) []string {
+	cells := []string{marker}
+	for _, col := range m.columns {
+		value, ok := cell(listColumns[col.name])
+		if !ok {
+			continue
+		}
+		text := fitCell(formatCell(value), col.width)
+		switch col.name {
+		case "name", "channel":
+			text = m.hlText("StreamName", m.highlightSearch("StreamName", tview.Escape(text)))
+		case "viewers", "rustlers":
+			text = m.hlText("Viewers", text)
+		case "game":
+			text = m.hlText("GameName", tview.Escape(text))
+		default:
+			text = tview.Escape(text)
+		}
+		cells = append(cells, text)
+	}
+	return cells
+}
+
+// Fixed first row of the table list mode with the names of the columns
+// {hasColumn} reports for the list. Columns without a width take up the
+// space left over
+func (m *MainPage) tableHeader(hasColumn func(def columnDef) bool) []*tview.TableCell {
+	// Above the marker
+	cells := []*tview.TableCell{tview.NewTableCell("  ").SetSelectable(false)}
+	for _, col := range m.columns {
+		if !hasColumn(listColumns[col.name]) {
+			continue
+		}
+		text := fitCell(strings.ToUpper(col.name), col.width)
+		cell := tview.NewTableCell(m.hlText("ColumnHeader", text)).SetSelectable(false)
+		if col.width == 0 {
+			cell.SetExpansion(1)
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+// Marker in front of table rows, of constant width to keep columns aligned
+func (m *MainPage) tableMarker(selected, isNew, playing bool) string {
+	marker := " "
+	if selected {
+		marker = m.hlText("Marker", "*")
+	} else if isNew {
+		marker = m.hlText("NewStream", "+")
+	}
+	if playing {
+		return marker + m.hlText("Playing", "▶")
+	}
+	return marker + " "
+}
+
+func (m *MainPage) sortTwitchIndex(ixs []int) {
+	def, ok := listColumns[m.sortBy]
+	if !ok || def.twitch == nil {
+		return
+	}
+	slices.SortStableFunc(ixs, func(a, b int) int {
+		c := compareCells(def.twitch(m, &m.streams.Twitch.Data[a]), def.twitch(m, &m.streams.Twitch.Data[b]))
+		if m.sortDesc {
+			return -c
+		}
+		return c
+	})
+}
+
+func (m *MainPage) sortStrimsIndex(ixs []int) {
+	def, ok := listColumns[m.sortBy]
+	if !ok || def.strims == nil {
+		return
+	}
+	slices.SortStableFunc(ixs, func(a, b int) int {
+		c := compareCells(def.strims(m, &m.streams.Strims.Data[a]), def.strims(m, &m.streams.Strims.Data[b]))
+		if m.sortDesc {
+			return -c
+		}
+		return c
+	})
+}
+
+// Switch both lists between one line per stream and the two line layout
+func (m *MainPage) applyListMode() {
+	table := m.listMode == "table"
+	m.twitchList.ShowSecondaryText(!table)
+	m.strimsList.ShowSecondaryText(!table)
+	if table {
+		m.twitchList.SetColumns(m.tableHeader(hasTwitchColumn))
+		m.strimsList.SetColumns(m.tableHeader(hasStrimsColumn))
+	} else {
+		m.twitchList.SetColumns(nil)
+		m.strimsList.SetColumns(nil)
+	}
+	m.refreshTwitchList()
+	m.refreshStrimsList()
+}
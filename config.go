@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Directory for user configuration, following $XDG_CONFIG_HOME
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "streamshower"), nil
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// Source the rc file in the config directory if it exists
+func (ui *UI) sourceRC() error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	err = ui.sourceFile(filepath.Join(dir, "streamshowerrc"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Execute every line in {path} as an ex command chain. Empty lines and lines
+// starting with " are skipped. All lines are executed, the first error is
+// returned
+func (ui *UI) sourceFile(path string) error {
+	f, err := os.Open(expandHome(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var firstErr error
+	scanner := bufio.NewScanner(f)
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "\"") {
+			continue
+		}
+		err = ui.execCommandChainSilent(":" + strings.TrimPrefix(line, ":"))
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s:%d: %w", filepath.Base(path), lineNr, err)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
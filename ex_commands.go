@@ -3,16 +3,26 @@ package main
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"math"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
+
+	"github.com/rivo/tview"
 )
 
 type CommandRegistry struct {
-	commands  []*ExCommand
-	history   []string
-	histIndex int
+	commands   []*ExCommand
+	history    []string
+	histIndex  int
+	histPrefix string // Text typed before browsing the history
+	histText   string // History entry last put in the command line
+	lastChange string // Last command chain that ran a Repeatable command
+	repeatable bool
+	userDepth  int
 }
 
 type ExCommand struct {
@@ -24,36 +34,118 @@ type ExCommand struct {
 	Usage       string
 	MinArgs     int
 	MaxArgs     int
+	Repeatable  bool // Whether `.` can repeat the command
+	TakesBar    bool // Sees | as part of its arguments instead of the next command
+	UserDefined bool // Defined at runtime with `:command`
 }
 
 func NewCommandRegistry() *CommandRegistry {
-	return &CommandRegistry{commands: defaultCommands}
+	return &CommandRegistry{commands: slices.Clone(defaultCommands)}
 }
 
 var defaultCommands = []*ExCommand{{
+	Name:        "changes",
+	Description: "List the streams that went live or offline and the titles and games that changed between fetches",
+	Usage:       "cha[nges[]",
+	MinArgs:     0,
+	MaxArgs:     0,
+	Execute: func(ui *UI, args []string, bang bool) error {
+		if len(ui.mainPage.changeLog.entries) == 0 {
+			return errors.New("no changes since the first fetch")
+		}
+		ui.mainPage.streamInfo.Clear()
+		ui.mainPage.streamInfo.ScrollTo(0, 0)
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.describeChanges())
+		ui.mainPage.streamInfo.SetTitle("CHANGES")
+		return nil
+	},
+}, {
+	Name:        "colorscheme",
+	Description: "Load colorscheme {name}, builtin dark and light or colors/{name}.vim in the config directory",
+	Usage:       "colo[rscheme[] [name[]",
+	MinArgs:     0,
+	MaxArgs:     1,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":colorscheme ", colorschemeNames())
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		if len(args) == 0 {
+			ui.mainPage.appStatusText.SetText(ui.mainPage.colorscheme)
+			return nil
+		}
+		return ui.loadColorscheme(args[0])
+	},
+}, {
+	Name:        "command",
+	Description: "List user commands, or define {Name} to run {rhs}, ! replaces an existing one. <args>, <q-args> and <bang> are substituted in {rhs}",
+	Usage:       "com[mand[][![] [{Name} {rhs}[]",
+	MinArgs:     0,
+	MaxArgs:     math.MaxInt,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":command! ", ui.cmdRegistry.userCommandNames())
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		switch len(args) {
+		case 0:
+			var commands []byte
+			for _, cmd := range ui.cmdRegistry.commands {
+				if cmd.UserDefined {
+					commands = fmt.Appendf(commands, "%s %s\n", ui.mainPage.hlText("InfoLabel", fmt.Sprintf("%-12s", cmd.Name)), cmd.Description)
+				}
+			}
+			if len(commands) == 0 {
+				return errors.New("no user commands defined")
+			}
+			ui.mainPage.streamInfo.Clear()
+			ui.mainPage.streamInfo.ScrollTo(0, 0)
+			_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+			_, _ = ui.mainPage.streamInfo.Write(commands)
+			ui.mainPage.streamInfo.SetTitle("COMMANDS")
+			return nil
+		case 1:
+			return fmt.Errorf("argument required for command: %s", args[0])
+		default:
+			rhs := strings.Join(args[1:], " ")
+			rhs = strings.ReplaceAll(rhs, "<Bar>", "|")
+			return ui.cmdRegistry.addUserCommand(args[0], rhs, bang)
+		}
+	},
+}, {
 	Name:        "copyurl",
 	Description: "Copy url of stream by the chosen method",
 	Usage:       "c[opyurl[] {method}",
 	MinArgs:     1,
 	MaxArgs:     1,
+	Repeatable:  true,
 	Complete: func(ui *UI, s string, bang bool) []string {
-		return matchCompletion(s, ":copyurl ", []string{"chat", "embed", "homepage", "mpv", "strims"})
+		return matchCompletion(s, ":copyurl ", ui.openMethods())
 	},
 	Execute: func(ui *UI, args []string, bang bool) error {
-		switch args[0] {
-		case "chat":
-			return ui.copySelectedStreamToClipboard(lnkOpenChat)
-		case "embed":
-			return ui.copySelectedStreamToClipboard(lnkOpenEmbed)
-		case "homepage":
-			return ui.copySelectedStreamToClipboard(lnkOpenHomePage)
-		case "mpv":
-			return ui.copySelectedStreamToClipboard(lnkOpenMpv)
-		case "strims":
-			return ui.copySelectedStreamToClipboard(lnkOpenStrims)
-		default:
-			return fmt.Errorf("unsupported method")
+		method, err := ui.parseOpenMethod(args[0])
+		if err != nil {
+			return err
 		}
+		return ui.copySelectedStreamToClipboard(method)
+	},
+}, {
+	Name:        "delcommand",
+	Description: "Delete the user command {Name}",
+	Usage:       "d[elcommand[] {Name}",
+	MinArgs:     1,
+	MaxArgs:     1,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":delcommand ", ui.cmdRegistry.userCommandNames())
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		ix := slices.IndexFunc(ui.cmdRegistry.commands, func(cmd *ExCommand) bool {
+			return cmd.UserDefined && cmd.Name == args[0]
+		})
+		if ix == -1 {
+			return fmt.Errorf("no such user command: %s", args[0])
+		}
+		ui.cmdRegistry.commands = slices.Delete(ui.cmdRegistry.commands, ix, ix+1)
+		return nil
 	},
 }, {
 	Name:        "echo",
@@ -71,19 +163,15 @@ var defaultCommands = []*ExCommand{{
 	Usage:       "fo[cus[] {list=twitch|strims|toggle}",
 	MinArgs:     1,
 	MaxArgs:     1,
+	Repeatable:  true,
 	Complete: func(ui *UI, s string, bang bool) []string {
 		return matchCompletion(s, ":focus ", []string{"strims", "toggle", "twitch"})
 	},
 	Execute: func(ui *UI, args []string, bang bool) error {
 		if args[0] == "twitch" || (args[0] == "toggle" && ui.mainPage.focusedList == ui.mainPage.strimsList) {
-			ui.app.SetFocus(ui.mainPage.twitchList)
-			ui.mainPage.focusedList = ui.mainPage.twitchList
-			ui.mainPage.refreshTwitchList()
+			ui.focusList(ui.mainPage.twitchList)
 		} else if args[0] == "strims" || (args[0] == "toggle" && ui.mainPage.focusedList == ui.mainPage.twitchList) {
-			ui.enableStrimsList()
-			ui.app.SetFocus(ui.mainPage.strimsList)
-			ui.mainPage.focusedList = ui.mainPage.strimsList
-			ui.mainPage.refreshStrimsList()
+			ui.focusList(ui.mainPage.strimsList)
 		} else {
 			return fmt.Errorf("unknown list %s", args[0])
 		}
@@ -95,6 +183,7 @@ var defaultCommands = []*ExCommand{{
 	Usage:       "g[lobal[][![]/{pattern}/{cmd}",
 	MinArgs:     1,
 	MaxArgs:     math.MaxInt,
+	Repeatable:  true,
 	Complete: func(ui *UI, s string, bang bool) []string {
 		var filter *FilterInput
 		switch ui.mainPage.focusedList {
@@ -113,6 +202,10 @@ var defaultCommands = []*ExCommand{{
 		ui.mainPage.applyFilterFromArg(strings.Join(args, " "), bang, false)
 		return nil
 	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		ui.mainPage.applyFilterFromArg(strings.Join(args, " "), bang, false)
+		return nil
+	},
 }, {
 	Name:        "help",
 	Description: "Show help for all commands, or those matching [subject[] if provided",
@@ -137,18 +230,18 @@ var defaultCommands = []*ExCommand{{
 		switch len(args) {
 		case 0:
 			for _, bh := range builtinHelps {
-				mappings = fmt.Appendf(mappings, "([::b]builtin[::-]) [red]%s[-]\n  %s\n", strings.Join(bh.Names, "[-] or [red]"), bh.Description)
+				mappings = ui.mainPage.appendBuiltinHelp(mappings, bh)
 			}
 			for _, cmd := range ui.cmdRegistry.commands {
-				mappings = fmt.Appendf(mappings, ":[red]%s[-]\n  %s\n", cmd.Usage, cmd.Description)
+				mappings = fmt.Appendf(mappings, ":%s\n  %s\n", ui.mainPage.hlText("InfoLabel", cmd.Usage), cmd.Description)
 			}
 		case 1:
 			for _, bh := range matchPossibleBuiltinHelps(args[0]) {
-				mappings = fmt.Appendf(mappings, "([::b]builtin[::-]) [red]%s[-]\n  %s\n", strings.Join(bh.Names, "[-] or [red]"), bh.Description)
+				mappings = ui.mainPage.appendBuiltinHelp(mappings, bh)
 			}
 			if cmdName, ok := strings.CutPrefix(args[0], ":"); ok {
 				for _, cmd := range ui.cmdRegistry.matchPossibleCommands(cmdName) {
-					mappings = fmt.Appendf(mappings, ":[red]%s[-]\n  %s\n", cmd.Usage, cmd.Description)
+					mappings = fmt.Appendf(mappings, ":%s\n  %s\n", ui.mainPage.hlText("InfoLabel", cmd.Usage), cmd.Description)
 				}
 			}
 			if len(mappings) == 0 {
@@ -157,11 +250,156 @@ var defaultCommands = []*ExCommand{{
 		}
 		ui.mainPage.streamInfo.Clear()
 		ui.mainPage.streamInfo.ScrollTo(0, 0)
-		_, _ = ui.mainPage.streamInfo.Write([]byte("--- [orange::b]<C-f>/<C-b> to scroll up/down in the info window[-::-] ---\n"))
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
 		_, _ = ui.mainPage.streamInfo.Write(mappings)
 		ui.mainPage.streamInfo.SetTitle("HELP")
 		return nil
 	},
+}, {
+	Name:        "highlight",
+	Description: "List the highlight groups, show {group} or set its colors and attributes (b d i l r s u), NONE resets one",
+	Usage:       "hi[ghlight[] [group [fg={color}[] [bg={color}[] [attr={attrs}[][]",
+	MinArgs:     0,
+	MaxArgs:     4,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		if strings.Contains(s, " ") {
+			return nil
+		}
+		return matchCompletion(s, ":highlight ", slices.Sorted(maps.Keys(ui.mainPage.highlights)))
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		return ui.highlightCommand(args)
+	},
+}, {
+	Name:        "history",
+	Description: "Show the {cmd} line history or the {streams} opened before, {open} or {goto} entry {n} of the latter",
+	Usage:       "his[tory[] {cmd|streams|open {n}|goto {n}}",
+	MinArgs:     1,
+	MaxArgs:     2,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":history ", []string{"cmd", "goto", "open", "streams"})
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		return ui.historyCommand(args)
+	},
+}, {
+	Name:        "infotemplate",
+	Description: "Show which templates render the stream info, reload them from info/{twitch,strims}.tmpl in the config directory. see `:h info-template`",
+	Usage:       "i[nfotemplate[] [reload[]",
+	MinArgs:     0,
+	MaxArgs:     1,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":infotemplate ", []string{"reload"})
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		return ui.infoTemplateCommand(args)
+	},
+}, {
+	Name:        "jobs",
+	Description: "List the players and browsers launched by `:open`",
+	Usage:       "j[obs[]",
+	MinArgs:     0,
+	MaxArgs:     0,
+	Execute: func(ui *UI, args []string, bang bool) error {
+		jobs := ui.jobRegistry.describe(ui.mainPage)
+		if len(jobs) == 0 {
+			return errors.New("no jobs")
+		}
+		ui.mainPage.streamInfo.Clear()
+		ui.mainPage.streamInfo.ScrollTo(0, 0)
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+		_, _ = ui.mainPage.streamInfo.Write(jobs)
+		ui.mainPage.streamInfo.SetTitle("JOBS")
+		return nil
+	},
+}, {
+	Name:        "kill",
+	Description: "Terminate the running {job}, ! kills it forcefully",
+	Usage:       "kill[![] {job}",
+	MinArgs:     1,
+	MaxArgs:     1,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":kill ", ui.jobRegistry.runningIDs())
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid job: %s", args[0])
+		}
+		job, err := ui.jobRegistry.get(id)
+		if err != nil {
+			return err
+		}
+		err = ui.jobRegistry.kill(job, bang)
+		if err != nil {
+			return err
+		}
+		ui.refreshPlaying()
+		return nil
+	},
+}, {
+	Name:        "killall",
+	Description: "Terminate all running jobs, ! kills them forcefully",
+	Usage:       "killa[ll[][![]",
+	MinArgs:     0,
+	MaxArgs:     0,
+	Execute: func(ui *UI, args []string, bang bool) error {
+		var errs []error
+		for _, job := range ui.jobRegistry.running() {
+			errs = append(errs, ui.jobRegistry.kill(job, bang))
+		}
+		ui.refreshPlaying()
+		return errors.Join(errs...)
+	},
+}, {
+	Name:        "launcher",
+	Description: "List launchers, or open {method} for [service[] with {command}, a template over the stream and its URL that can use |. [service[] is one with a url template, see `:urltemplate`. ! removes the launcher. see `:h launcher-options`",
+	Usage:       "l[auncher[][![] [{method} [service[] {command}[]",
+	MinArgs:     0,
+	MaxArgs:     math.MaxInt,
+	TakesBar:    true,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":launcher ", ui.openMethods())
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		if len(args) == 0 {
+			launchers := ui.launcherRegistry.describe(ui.mainPage)
+			if len(launchers) == 0 {
+				return errors.New("no launchers defined")
+			}
+			ui.mainPage.streamInfo.Clear()
+			ui.mainPage.streamInfo.ScrollTo(0, 0)
+			_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+			_, _ = ui.mainPage.streamInfo.Write(launchers)
+			ui.mainPage.streamInfo.SetTitle("LAUNCHERS")
+			return nil
+		}
+		method, err := ui.parseOpenMethod(args[0])
+		if err != nil {
+			return err
+		}
+		args = args[1:]
+		var service string
+		if len(args) > 0 && slices.Contains(ui.knownServices(), args[0]) {
+			service = args[0]
+			args = args[1:]
+		}
+		if bang {
+			if len(args) > 0 {
+				return fmt.Errorf("trailing characters: %s", strings.Join(args, " "))
+			}
+			return ui.launcherRegistry.remove(method, service)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("argument required for command: launcher")
+		}
+		launcher, err := parseLauncher(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		ui.launcherRegistry.set(method, service, launcher)
+		return nil
+	},
 }, {
 	Name:        "map",
 	Description: "Print mappings or map keypress [lhs[] into command [rhs[]. <Bar> replaces | in mappings",
@@ -196,30 +434,43 @@ var defaultCommands = []*ExCommand{{
 				return fmt.Errorf("no mapping found for %s", args[0])
 			}
 		default:
-			if err := validateMappingKey(args[0]); err != nil {
+			lhs, err := canonicalMappingKey(args[0])
+			if err != nil {
 				return err
 			}
-			if args[0] == ":" {
+			if lhs == ":" {
 				return errors.New("unsupported mapping using ':'")
 			}
 			rhs := strings.Join(args[1:], " ")
 			rhs = strings.ReplaceAll(rhs, "<Bar>", "|")
-			ui.mapRegistry.mappings[args[0]] = rhs
+			ui.mapRegistry.mappings[lhs] = rhs
 			return nil
 		}
 		sort.Strings(keys)
 		var mappings []byte
 		for _, lhs := range keys {
 			rhs := ui.mapRegistry.mappings[lhs]
-			mappings = fmt.Appendf(mappings, "[red]%-7s[-] %s\n", lhs, rhs)
+			mappings = fmt.Appendf(mappings, "%s %s\n", ui.mainPage.hlText("InfoLabel", fmt.Sprintf("%-7s", lhs)), rhs)
 		}
 		ui.mainPage.streamInfo.Clear()
 		ui.mainPage.streamInfo.ScrollTo(0, 0)
-		_, _ = ui.mainPage.streamInfo.Write([]byte("--- [orange::b]<C-f>/<C-b> to scroll up/down in the info window[-::-] ---\n"))
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
 		_, _ = ui.mainPage.streamInfo.Write(mappings)
 		ui.mainPage.streamInfo.SetTitle("MAPPINGS")
 		return nil
 	},
+}, {
+	Name:        "mpv",
+	Description: "Control the mpv started with `:set mpvipc` by {cmd=add|next|pause|prev|quality {format}|status|stop|volume {[+-]n}}",
+	Usage:       "mp[v[] {cmd} [arg[]",
+	MinArgs:     1,
+	MaxArgs:     2,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":mpv ", []string{"add", "next", "pause", "prev", "quality", "status", "stop", "volume"})
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		return ui.mpvCommand(args)
+	},
 }, {
 	Name:        "nohlsearch",
 	Description: "Stop highlighting search",
@@ -232,30 +483,31 @@ var defaultCommands = []*ExCommand{{
 		ui.mainPage.refreshStrimsList()
 		return nil
 	},
+}, {
+	Name:        "normal",
+	Description: "Execute normal mode {keys} in the current list, ! ignores mappings",
+	Usage:       "nor[mal[][![] {keys}",
+	MinArgs:     1,
+	MaxArgs:     math.MaxInt,
+	Execute: func(ui *UI, args []string, bang bool) error {
+		return ui.runNormalKeys(strings.Join(args, " "), bang)
+	},
 }, {
 	Name:        "open",
 	Description: "Open stream with the chosen method",
 	Usage:       "o[pen[] {method}",
 	MinArgs:     1,
 	MaxArgs:     1,
+	Repeatable:  true,
 	Complete: func(ui *UI, s string, bang bool) []string {
-		return matchCompletion(s, ":open ", []string{"chat", "embed", "homepage", "mpv", "strims"})
+		return matchCompletion(s, ":open ", ui.openMethods())
 	},
 	Execute: func(ui *UI, args []string, bang bool) error {
-		switch args[0] {
-		case "chat":
-			return ui.openSelectedStream(lnkOpenChat)
-		case "embed":
-			return ui.openSelectedStream(lnkOpenEmbed)
-		case "homepage":
-			return ui.openSelectedStream(lnkOpenHomePage)
-		case "mpv":
-			return ui.openSelectedStream(lnkOpenMpv)
-		case "strims":
-			return ui.openSelectedStream(lnkOpenStrims)
-		default:
-			return fmt.Errorf("unsupported method")
+		method, err := ui.parseOpenMethod(args[0])
+		if err != nil {
+			return err
 		}
+		return ui.openSelectedStream(method)
 	},
 }, {
 	Name:        "resize",
@@ -294,6 +546,15 @@ var defaultCommands = []*ExCommand{{
 		}
 		return nil
 	},
+}, {
+	Name:        "source",
+	Description: "Execute each line of {file} as a command",
+	Usage:       "so[urce[] {file}",
+	MinArgs:     1,
+	MaxArgs:     1,
+	Execute: func(ui *UI, args []string, bang bool) error {
+		return ui.sourceFile(args[0])
+	},
 }, {
 	Name:        "sync",
 	Description: "Syncronize all streams on the client side",
@@ -304,30 +565,34 @@ var defaultCommands = []*ExCommand{{
 		select {
 		case ui.updateStreamsCh <- struct{}{}:
 		default:
-			return errors.New("[red]Skipped fetching streams, try again later...[-]")
+			return errors.New("Skipped fetching streams, try again later...")
 		}
 		return nil
 	},
 }, {
 	Name:        "set",
-	Description: "Set [option[] or [no{option}[], ! toggles the value. see `:h option-list`",
-	Usage:       "se[t[][![] [option[]",
+	Description: "Set [option[], [no{option}[] or [{option}={value}[], ! toggles the value, {option}? shows it. see `:h option-list`",
+	Usage:       "se[t[][![] [option[]...",
 	MinArgs:     1,
-	MaxArgs:     1,
+	MaxArgs:     math.MaxInt,
 	Complete: func(ui *UI, s string, bang bool) []string {
-		options := []string{"strims", "winopen"}
 		var cmdPfx strings.Builder
 		cmdPfx.WriteString(":set")
 		if bang {
 			cmdPfx.WriteString("!")
 		}
 		cmdPfx.WriteString(" ")
+		var matches []string
+		for _, opt := range valueOptions {
+			if strings.HasPrefix(opt, s) {
+				matches = append(matches, cmdPfx.String()+opt+"=")
+			}
+		}
 		if strings.HasPrefix(s, "no") {
 			cmdPfx.WriteString("no")
 			s = strings.TrimPrefix(s, "no")
 		}
-		var matches []string
-		for _, opt := range options {
+		for _, opt := range boolOptions {
 			if strings.HasPrefix(opt, s) {
 				matches = append(matches, cmdPfx.String()+opt)
 			}
@@ -335,39 +600,26 @@ var defaultCommands = []*ExCommand{{
 		return matches
 	},
 	Execute: func(ui *UI, args []string, bang bool) error {
-		switch len(args) {
-		case 1:
-			var prefixno bool
-			arg := args[0]
-			if strings.HasPrefix(arg, "no") {
-				prefixno = true
-				arg = strings.TrimPrefix(arg, "no")
-			}
-			switch arg {
-			case "strims":
-				if bang {
-					ui.toggleStrimsList()
-				} else if prefixno {
-					ui.disableStrimsList()
-				} else {
-					ui.enableStrimsList()
-				}
-				ui.mainPage.refreshTwitchList()
-				ui.mainPage.refreshStrimsList()
-			case "winopen":
-				if bang {
-					ui.mainPage.winopen = !ui.mainPage.winopen
-				} else if prefixno {
-					ui.mainPage.winopen = false
-				} else {
-					ui.mainPage.winopen = true
-				}
-			default:
-				return fmt.Errorf("unknown option %s", arg)
+		for _, arg := range joinEscapedSpaces(args) {
+			err := ui.setOption(arg, bang)
+			if err != nil {
+				return err
 			}
 		}
 		return nil
 	},
+}, {
+	Name:        "queue",
+	Description: "Show the watch-later queue, {add} the selected streams to it, {open} all of it in mpv or {clear} it",
+	Usage:       "que[ue[] [add|clear|open[]",
+	MinArgs:     0,
+	MaxArgs:     1,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":queue ", []string{"add", "clear", "open"})
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		return ui.queueCommand(args)
+	},
 }, {
 	Name:        "quit",
 	Description: "Quit the app",
@@ -414,8 +666,12 @@ var defaultCommands = []*ExCommand{{
 		return matches
 	},
 	Execute: func(ui *UI, args []string, bang bool) error {
-		if _, ok := ui.mapRegistry.mappings[args[0]]; ok {
-			delete(ui.mapRegistry.mappings, args[0])
+		lhs, err := canonicalMappingKey(args[0])
+		if err != nil {
+			return err
+		}
+		if _, ok := ui.mapRegistry.mappings[lhs]; ok {
+			delete(ui.mapRegistry.mappings, lhs)
 			return nil
 		}
 		return fmt.Errorf("no mapping found for %s", args[0])
@@ -430,16 +686,56 @@ var defaultCommands = []*ExCommand{{
 		select {
 		case ui.forceRemoteUpdateCh <- struct{}{}:
 		default:
-			return errors.New("[red]Skipped remote update, try again later...[-]")
+			return errors.New("Skipped remote update, try again later...")
 		}
 		return nil
 	},
+}, {
+	Name:        "urltemplate",
+	Description: "List url templates, or set the url for {method} and {service} (* for any) to {template}, which can use |, creating the method if needed. ! removes the template. see `:h template-fields`",
+	Usage:       "ur[ltemplate[][![] [{method} [{service} {template}[][]",
+	MinArgs:     0,
+	MaxArgs:     math.MaxInt,
+	TakesBar:    true,
+	Complete: func(ui *UI, s string, bang bool) []string {
+		return matchCompletion(s, ":urltemplate ", ui.openMethods())
+	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		if len(args) <= 1 {
+			var method string
+			if len(args) == 1 {
+				method = args[0]
+			}
+			templates := ui.describeURLTemplates(method)
+			if len(templates) == 0 {
+				return fmt.Errorf("no url templates found for %s", method)
+			}
+			ui.mainPage.streamInfo.Clear()
+			ui.mainPage.streamInfo.ScrollTo(0, 0)
+			_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+			_, _ = ui.mainPage.streamInfo.Write(templates)
+			ui.mainPage.streamInfo.SetTitle("URL TEMPLATES")
+			return nil
+		}
+		method := OpenMethod(args[0])
+		if bang {
+			if len(args) > 2 {
+				return fmt.Errorf("trailing characters: %s", args[2])
+			}
+			return ui.removeURLTemplate(method, args[1])
+		}
+		if len(args) < 3 {
+			return fmt.Errorf("argument required for command: urltemplate")
+		}
+		return ui.setURLTemplate(method, args[1], strings.Join(args[2:], " "))
+	},
 }, {
 	Name:        "vglobal",
 	Description: "Filter {cmd=d|p} lines NOT matching {pattern}, ! filters all lists",
 	Usage:       "v[global[][![]/{pattern}/{cmd}",
 	MinArgs:     1,
 	MaxArgs:     math.MaxInt,
+	Repeatable:  true,
 	Complete: func(ui *UI, s string, bang bool) []string {
 		var filter *FilterInput
 		switch ui.mainPage.focusedList {
@@ -458,27 +754,88 @@ var defaultCommands = []*ExCommand{{
 		ui.mainPage.applyFilterFromArg(strings.Join(args, " "), bang, true)
 		return nil
 	},
+	Execute: func(ui *UI, args []string, bang bool) error {
+		ui.mainPage.applyFilterFromArg(strings.Join(args, " "), bang, true)
+		return nil
+	},
 }, {
 	Name:        "windo",
 	Description: "execute {command} once for each list",
 	Usage:       "w[indo[] {cmd}",
 	MinArgs:     1,
-	MaxArgs:     1,
+	MaxArgs:     math.MaxInt,
 	Execute: func(ui *UI, args []string, bang bool) error {
 		ui.mainPage.focusedList = ui.mainPage.twitchList
-		err := ui.execCommand(":" + strings.Join(args, ""))
+		err := ui.execCommand(":" + strings.Join(args, " "))
 		if err != nil {
 			return err
 		}
 		ui.mainPage.focusedList = ui.mainPage.strimsList
-		err = ui.execCommand(":" + strings.Join(args, ""))
-		if err != nil {
-			return nil
-		}
-		return nil
+		return ui.execCommand(":" + strings.Join(args, " "))
 	},
 }}
 
+const maxUserCommandDepth = 100
+
+func (r *CommandRegistry) userCommandNames() []string {
+	var names []string
+	for _, cmd := range r.commands {
+		if cmd.UserDefined {
+			names = append(names, cmd.Name)
+		}
+	}
+	return names
+}
+
+// Register the user command {name} that runs the command chain {rhs}
+func (r *CommandRegistry) addUserCommand(name string, rhs string, replace bool) error {
+	if !unicode.IsUpper([]rune(name)[0]) {
+		return fmt.Errorf("user defined commands must start with an uppercase letter: %s", name)
+	}
+	if cmdName, rest := extractCmdName(name); rest != "" || cmdName != name {
+		return fmt.Errorf("invalid command name: %s", name)
+	}
+	ix := slices.IndexFunc(r.commands, func(cmd *ExCommand) bool {
+		return cmd.Name == name
+	})
+	if ix != -1 && !replace {
+		return fmt.Errorf("command already exists, add ! to replace it: %s", name)
+	}
+	rhs = strings.TrimPrefix(strings.TrimSpace(rhs), ":")
+	cmd := &ExCommand{
+		Name:        name,
+		Description: tview.Escape(rhs),
+		Usage:       name + "[![] [args[]",
+		MinArgs:     0,
+		MaxArgs:     math.MaxInt,
+		UserDefined: true,
+		Execute: func(ui *UI, args []string, bang bool) error {
+			if ui.cmdRegistry.userDepth >= maxUserCommandDepth {
+				return fmt.Errorf("recursive user command: %s", name)
+			}
+			ui.cmdRegistry.userDepth++
+			defer func() { ui.cmdRegistry.userDepth-- }()
+			joined := strings.Join(args, " ")
+			bangStr := ""
+			if bang {
+				bangStr = "!"
+			}
+			cmdLine := strings.NewReplacer(
+				"<args>", joined,
+				"<q-args>", strconv.Quote(joined),
+				"<bang>", bangStr,
+			).Replace(rhs)
+			return ui.execCommandChainSilent(":" + cmdLine)
+		},
+	}
+	if ix != -1 {
+		r.commands[ix] = cmd
+	} else {
+		r.commands = append(r.commands, cmd)
+	}
+	return nil
+}
+
 func matchCompletion(s string, prefix string, options []string) []string {
 	var matches []string
 	for _, method := range options {
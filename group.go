@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rivo/tview"
+)
+
+var groupByColumns = []string{"game", "language", "service"}
+
+// Streams and summed viewers of a group of streams
+type GroupStats struct {
+	streams int
+	viewers int
+}
+
+// Interleave the stream indexes {ixs} with a header row, marked by -1 in the
+// index mapping, in front of each group. Groups keep the order in which they
+// first appear and folded groups only show their header
+func (f *FilterInput) groupRows(ixs []int, key func(ix int) string, viewers func(ix int) int) {
+	var order []string
+	members := make(map[string][]int)
+	f.groupStats = make(map[string]GroupStats)
+	for _, ix := range ixs {
+		group := key(ix)
+		if group == "" {
+			group = "None"
+		}
+		if _, ok := members[group]; !ok {
+			order = append(order, group)
+		}
+		members[group] = append(members[group], ix)
+		stats := f.groupStats[group]
+		stats.streams++
+		stats.viewers += viewers(ix)
+		f.groupStats[group] = stats
+	}
+	if f.folded == nil {
+		f.folded = make(map[string]bool)
+	}
+	f.indexMapping = nil
+	f.rowGroups = nil
+	for _, group := range order {
+		f.indexMapping = append(f.indexMapping, -1)
+		f.rowGroups = append(f.rowGroups, group)
+		if f.folded[group] {
+			continue
+		}
+		for _, ix := range members[group] {
+			f.indexMapping = append(f.indexMapping, ix)
+			f.rowGroups = append(f.rowGroups, group)
+		}
+	}
+}
+
+func foldMarker(f *FilterInput, group string) string {
+	if f.folded[group] {
+		return "▸"
+	}
+	return "▾"
+}
+
+// The main and secondary text of the header row of {group}
+func (m *MainPage) groupHeader(f *FilterInput, group string) (string, string) {
+	stats := f.groupStats[group]
+	return m.hlText("GroupHeader", foldMarker(f, group)+" "+tview.Escape(group)),
+		" " + m.hlText("InfoDim", fmt.Sprintf("%d streams, %d viewers", stats.streams, stats.viewers))
+}
+
+// Cells of the header row of {group} in the table list mode, the group and
+// its number of streams in the first column and the summed viewers under the
+// viewers
+func (m *MainPage) tableGroupRow(f *FilterInput, group string, hasColumn func(def columnDef) bool) []string {
+	stats := f.groupStats[group]
+	cells := []string{m.hlText("GroupHeader", foldMarker(f, group)) + " "}
+	for _, col := range m.columns {
+		if !hasColumn(listColumns[col.name]) {
+			continue
+		}
+		var text string
+		switch {
+		case len(cells) == 1:
+			name := fitCell(fmt.Sprintf("%s (%d)", group, stats.streams), col.width)
+			text = m.hlText("GroupHeader", tview.Escape(name))
+		case col.name == "viewers" || col.name == "rustlers":
+			text = m.hlText("Viewers", fitCell(strconv.Itoa(stats.viewers), col.width))
+		default:
+			text = fitCell("", col.width)
+		}
+		cells = append(cells, text)
+	}
+	return cells
+}
+
+func (m *MainPage) showGroupInfo(f *FilterInput, group string) {
+	stats := f.groupStats[group]
+	m.streamInfo.SetTitle(group)
+	_, _ = fmt.Fprintf(m.streamInfo, "%s: %d\n", m.hlText("InfoLabel", "Streams"), stats.streams)
+	_, _ = fmt.Fprintf(m.streamInfo, "%s: %d\n", m.hlText("InfoLabel", "Viewers"), stats.viewers)
+}
+
+// Run the command z{key}, a fold command on the group under the cursor or zz
+// to redraw
+func (ui *UI) execFoldKey(key string) {
+	if key == "z" || key == "." {
+		ui.redrawMid()
+		return
+	}
+	list := ui.mainPage.focusedList
+	f := ui.mainPage.filterFor(list)
+	if f.rowGroups == nil {
+		return
+	}
+	group := ""
+	if cur := list.GetCurrentItem(); cur < len(f.rowGroups) {
+		group = f.rowGroups[cur]
+	}
+	switch key {
+	case "a":
+		f.folded[group] = !f.folded[group]
+	case "c":
+		f.folded[group] = true
+	case "o":
+		f.folded[group] = false
+	case "M":
+		for _, g := range f.rowGroups {
+			f.folded[g] = true
+		}
+	case "R":
+		clear(f.folded)
+	default:
+		return
+	}
+	ui.refreshFocusedList()
+	// Keep the cursor on the group that was folded or unfolded
+	for i, g := range f.rowGroups {
+		if g == group && f.indexMapping[i] < 0 {
+			list.SetCurrentItem(i)
+			break
+		}
+	}
+}
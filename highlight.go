@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Colors and attributes of a highlight group, empty fields keep the default
+type Highlight struct {
+	Fg   string
+	Bg   string
+	Attr string
+}
+
+var darkHighlights = map[string]Highlight{
+	"ColumnHeader":  {Attr: "b"},
+	"CommandLine":   {Bg: "black"},
+	"FetchTime":     {Fg: "black", Bg: "orange"},
+	"GameName":      {Fg: "green", Attr: "u"},
+	"GroupHeader":   {Attr: "b"},
+	"InfoDim":       {Fg: "lightgray"},
+	"InfoHeader":    {Fg: "orange", Attr: "b"},
+	"InfoLabel":     {Fg: "red"},
+	"Marker":        {Fg: "yellow"},
+	"NewStream":     {Fg: "aqua", Attr: "b"},
+	"NsfwTitle":     {Fg: "red", Attr: "u"},
+	"Playing":       {Fg: "green"},
+	"Search":        {Fg: "red"},
+	"StatusError":   {Fg: "red"},
+	"StatusInfo":    {Fg: "yellow"},
+	"StatusOk":      {Fg: "green"},
+	"StatusWarning": {Fg: "orange"},
+	"StreamName":    {},
+	"StrimsTitle":   {Fg: "green", Attr: "u"},
+	"Viewers":       {},
+}
+
+var lightHighlights = map[string]Highlight{
+	"ColumnHeader":  {Attr: "b"},
+	"CommandLine":   {Fg: "black", Bg: "lightgray"},
+	"FetchTime":     {Fg: "white", Bg: "darkblue"},
+	"GameName":      {Fg: "darkblue", Attr: "u"},
+	"GroupHeader":   {Attr: "b"},
+	"InfoDim":       {Fg: "gray"},
+	"InfoHeader":    {Fg: "darkblue", Attr: "b"},
+	"InfoLabel":     {Fg: "darkred"},
+	"Marker":        {Fg: "darkmagenta"},
+	"NewStream":     {Fg: "teal", Attr: "b"},
+	"NsfwTitle":     {Fg: "red", Attr: "u"},
+	"Playing":       {Fg: "darkgreen"},
+	"Search":        {Fg: "red", Attr: "b"},
+	"StatusError":   {Fg: "red"},
+	"StatusInfo":    {Fg: "darkblue"},
+	"StatusOk":      {Fg: "darkgreen"},
+	"StatusWarning": {Fg: "darkorange"},
+	"StreamName":    {},
+	"StrimsTitle":   {Fg: "darkblue", Attr: "u"},
+	"Viewers":       {},
+}
+
+var builtinColorschemes = map[string]map[string]Highlight{
+	"dark":  darkHighlights,
+	"light": lightHighlights,
+}
+
+const highlightAttrs = "bdilrsu"
+
+// The style tag starting text in {group}
+func (m *MainPage) hl(group string) string {
+	h := m.highlights[group]
+	return fmt.Sprintf("[%s:%s:%s]", orDefault(h.Fg, "-"), orDefault(h.Bg, "-"), orDefault(h.Attr, "-"))
+}
+
+// {text} in the style of {group}, resetting the style afterwards
+func (m *MainPage) hlText(group string, text string) string {
+	return m.hl(group) + text + "[-:-:-]"
+}
+
+// {text} in the style of {group} inside text in the style of {outer}, going
+// back to the style of {outer} afterwards
+func (m *MainPage) hlTextIn(outer, group string, text string) string {
+	return m.hl(group) + text + m.hl(outer)
+}
+
+// First line of listings in the info window
+func (m *MainPage) scrollHint() []byte {
+	return []byte("--- " + m.hlText("InfoHeader", "<C-f>/<C-b> to scroll up/down in the info window") + " ---\n")
+}
+
+func (m *MainPage) setStatus(group string, text string) {
+	if group == "StatusError" || group == "StatusWarning" {
+		m.lastError = text
+	}
+	m.appStatusText.SetText(m.hlText(group, text))
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func validColor(name string) bool {
+	return name == "" || name == "default" || tcell.GetColor(name) != tcell.ColorDefault
+}
+
+// Parse the fg=, bg= and attr= arguments of :highlight on top of {h}
+func parseHighlight(h Highlight, args []string) (Highlight, error) {
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return h, fmt.Errorf("expected key=value: %s", arg)
+		}
+		if value == "NONE" {
+			value = ""
+		}
+		switch key {
+		case "fg", "bg":
+			if !validColor(value) {
+				return h, fmt.Errorf("unknown color %s", value)
+			}
+			if key == "fg" {
+				h.Fg = value
+			} else {
+				h.Bg = value
+			}
+		case "attr":
+			for _, r := range value {
+				if !strings.ContainsRune(highlightAttrs, r) {
+					return h, fmt.Errorf("unknown attribute %c, expected one of %s", r, highlightAttrs)
+				}
+			}
+			h.Attr = value
+		default:
+			return h, fmt.Errorf("unknown key %s", key)
+		}
+	}
+	return h, nil
+}
+
+func (h Highlight) String() string {
+	return fmt.Sprintf("fg=%s bg=%s attr=%s", orDefault(h.Fg, "NONE"), orDefault(h.Bg, "NONE"), orDefault(h.Attr, "NONE"))
+}
+
+func (m *MainPage) describeHighlights() []byte {
+	var lines []byte
+	for _, group := range slices.Sorted(maps.Keys(m.highlights)) {
+		lines = fmt.Appendf(lines, "%-14s %s\n", m.hlText(group, group), m.highlights[group])
+	}
+	return lines
+}
+
+// Apply the highlight groups to the widgets that are not colored by tags and
+// redraw the lists
+func (ui *UI) applyHighlights() {
+	fetchTime := ui.mainPage.highlights["FetchTime"]
+	ui.mainPage.fetchTimeView.SetBackgroundColor(tcell.GetColor(orDefault(fetchTime.Bg, "default")))
+	ui.mainPage.fetchTimeView.SetTextColor(tcell.GetColor(orDefault(fetchTime.Fg, "default")))
+	commandLine := ui.mainPage.highlights["CommandLine"]
+	ui.mainPage.commandLine.SetFieldBackgroundColor(tcell.GetColor(orDefault(commandLine.Bg, "default")))
+	ui.mainPage.commandLine.SetFieldTextColor(tcell.GetColor(orDefault(commandLine.Fg, "default")))
+	ui.mainPage.applyListMode()
+}
+
+func (ui *UI) highlightCommand(args []string) error {
+	if len(args) == 0 {
+		ui.mainPage.streamInfo.Clear()
+		ui.mainPage.streamInfo.ScrollTo(0, 0)
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.describeHighlights())
+		ui.mainPage.streamInfo.SetTitle("HIGHLIGHTS")
+		return nil
+	}
+	group := args[0]
+	h, ok := ui.mainPage.highlights[group]
+	if !ok {
+		return fmt.Errorf("unknown highlight group %s", group)
+	}
+	if len(args) == 1 {
+		ui.mainPage.appStatusText.SetText(ui.mainPage.hlText(group, group) + " " + h.String())
+		return nil
+	}
+	h, err := parseHighlight(h, args[1:])
+	if err != nil {
+		return err
+	}
+	ui.mainPage.highlights[group] = h
+	ui.applyHighlights()
+	return nil
+}
+
+func colorsDir() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "colors"), nil
+}
+
+// Names of the builtin colorschemes and those in the colors directory
+func colorschemeNames() []string {
+	names := slices.Collect(maps.Keys(builtinColorschemes))
+	if dir, err := colorsDir(); err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if name, ok := strings.CutSuffix(entry.Name(), ".vim"); ok && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Reset the highlights to the dark theme, then apply the builtin theme {name}
+// or source colors/{name}.vim in the config directory, which takes
+// precedence
+func (ui *UI) loadColorscheme(name string) error {
+	ui.mainPage.highlights = maps.Clone(darkHighlights)
+	if builtin, ok := builtinColorschemes[name]; ok {
+		maps.Copy(ui.mainPage.highlights, builtin)
+	}
+	dir, err := colorsDir()
+	if err != nil {
+		return err
+	}
+	err = ui.sourceFile(filepath.Join(dir, name+".vim"))
+	if errors.Is(err, os.ErrNotExist) {
+		if _, ok := builtinColorschemes[name]; !ok {
+			return fmt.Errorf("cannot find colorscheme %s", name)
+		}
+		err = nil
+	}
+	ui.mainPage.colorscheme = name
+	ui.applyHighlights()
+	return err
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+)
+
+var defaultInfoTemplates = map[string]string{
+	"twitch": `{{hl "InfoLabel" "Title"}}: {{escape .Title}}
+{{hl "InfoLabel" "Viewers"}}: {{.ViewerCount}} {{sparkline .}} {{hl "InfoDim" (delta .)}}
+{{hl "InfoLabel" "Game"}}: {{with .GameName}}{{escape .}}{{else}}[::d]None[::-]{{end}}
+{{hl "InfoLabel" "Started At"}}: {{clock .StartedAt}} {{hl "InfoDim" (printf "(%s)" (ago .StartedAt))}}
+{{hl "InfoLabel" "Language"}}: {{.Language}}
+{{hl "InfoLabel" "Type"}}: {{.Type}}
+`,
+	"strims": `{{hl "InfoLabel" "Title"}}: {{if eq .Service "m3u8"}}{{escape .URL}}{{else}}{{escape .Title}}{{end}}
+{{hl "InfoLabel" "Rustlers"}}: {{.Rustlers}} {{hl "InfoDim" (printf "(%d afk)" .AfkRustlers)}} {{sparkline .}} {{hl "InfoDim" (delta .)}}
+{{hl "InfoLabel" "Service"}}: {{.Service}}
+{{hl "InfoLabel" "Viewers"}}: {{.Viewers}}
+{{hl "InfoLabel" "Live"}}: {{.Live}}
+{{hl "InfoLabel" "AFK"}}: {{.Afk}}
+`,
+}
+
+// Templates rendering the info pane of a list and the file each came from,
+// "" for the builtin one
+type InfoTemplates struct {
+	templates map[string]*template.Template
+	sources   map[string]string
+}
+
+// Days, hours and minutes of {d}
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	return fmt.Sprintf("%dd %dh %dm", hours/24, hours%24, int(d.Minutes())%60)
+}
+
+func (m *MainPage) infoTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"ago": func(t time.Time) string { return formatDuration(time.Since(t)) + " ago" },
+		"clock": func(t time.Time) string {
+			return t.Local().Format("15:04")
+		},
+		"delta":    m.viewerHistory.describeDelta,
+		"duration": formatDuration,
+		"escape": func(s string) string {
+			return tview.Escape(removeVariationSelectors(strings.ReplaceAll(s, "\n", " ")))
+		},
+		"hl":    m.hlText,
+		"since": time.Since,
+		"sparkline": func(data ls.StreamData) string {
+			return m.viewerHistory.trend(data).spark
+		},
+	}
+}
+
+// Parse the builtin info templates, replaced by info/{list}.tmpl in the config
+// directory where it exists
+func (m *MainPage) loadInfoTemplates() error {
+	templates := InfoTemplates{
+		templates: make(map[string]*template.Template),
+		sources:   make(map[string]string),
+	}
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for list, text := range defaultInfoTemplates {
+		path := filepath.Join(dir, "info", list+".tmpl")
+		userText, err := os.ReadFile(path)
+		if err == nil {
+			text = string(userText)
+			templates.sources[list] = path
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+		tmpl, err := template.New(list).Funcs(m.infoTemplateFuncs()).Parse(text)
+		if err != nil {
+			errs = append(errs, err)
+			tmpl = template.Must(template.New(list).Funcs(m.infoTemplateFuncs()).Parse(defaultInfoTemplates[list]))
+			delete(templates.sources, list)
+		}
+		templates.templates[list] = tmpl
+	}
+	m.infoTemplates = templates
+	return errors.Join(errs...)
+}
+
+// Render the info template of {list} for {data} into the info pane
+func (m *MainPage) renderStreamInfo(list string, title string, data any) {
+	m.streamInfo.SetTitle(title)
+	err := m.infoTemplates.templates[list].Execute(m.streamInfo, data)
+	if err != nil {
+		_, _ = m.streamInfo.Write([]byte("\n" + m.hlText("StatusError", tview.Escape(err.Error()))))
+	}
+}
+
+// Render the info pane again for the current item of the focused list
+func (m *MainPage) redrawStreamInfo() {
+	switch m.focusedList {
+	case m.twitchList:
+		m.updateTwitchStreamInfo(m.twitchList.GetCurrentItem(), "", "", 0)
+	case m.strimsList:
+		m.updateStrimsStreamInfo(m.strimsList.GetCurrentItem(), "", "", 0)
+	}
+}
+
+func (ui *UI) infoTemplateCommand(args []string) error {
+	if len(args) == 1 {
+		if args[0] != "reload" {
+			return fmt.Errorf("unknown argument %s", args[0])
+		}
+		err := ui.mainPage.loadInfoTemplates()
+		ui.mainPage.redrawStreamInfo()
+		if err != nil {
+			return err
+		}
+	}
+	var sources []string
+	for _, list := range []string{"twitch", "strims"} {
+		source := ui.mainPage.infoTemplates.sources[list]
+		if source == "" {
+			source = "builtin"
+		}
+		sources = append(sources, list+": "+source)
+	}
+	ui.mainPage.appStatusText.SetText(tview.Escape(strings.Join(sources, "  ")))
+	return nil
+}
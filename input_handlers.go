@@ -1,20 +1,40 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
 func (ui *UI) listInputHandler(event *tcell.EventKey) *tcell.EventKey {
 	var ok bool
-	if ui.mainPage.focusedList, ok = ui.app.GetFocus().(*tview.List); !ok {
+	if ui.mainPage.focusedList, ok = ui.app.GetFocus().(*StreamList); !ok {
 		panic("input handler called where it shouldn't have been")
 	}
 
 	var lhs, rhs string
 	lhs = encodeMappingKey(event)
+	if ui.mapDepth == 0 {
+		ui.macroRegistry.recordKey(lhs)
+	}
+	if ui.macroRegistry.pending != 0 {
+		var reg rune
+		if event.Key() == tcell.KeyRune {
+			reg = event.Rune()
+		}
+		ui.handleMacroRegister(reg)
+		if ui.mapDepth > 0 {
+			ui.mapDepth--
+		}
+		return nil
+	}
+	if ui.foldPending {
+		ui.foldPending = false
+		ui.execFoldKey(lhs)
+		if ui.mapDepth > 0 {
+			ui.mapDepth--
+		}
+		return nil
+	}
 	rhs, ok = ui.mapRegistry.mappings[lhs]
 	if ok {
 		if ui.mapDepth > 0 {
@@ -22,22 +42,57 @@ func (ui *UI) listInputHandler(event *tcell.EventKey) *tcell.EventKey {
 		}
 		keyStrings, err := ui.mapRegistry.resolveMappings(rhs)
 		if err != nil {
-			ui.mainPage.appStatusText.SetText(fmt.Sprintf("[%s]%s[-]", "orange", err.Error()))
+			ui.mainPage.setStatus("StatusWarning", err.Error())
 			ui.mapDepth = 0
 			return nil
 		}
 		ui.mapDepth += len(keyStrings)
-		err = ui.execCommandChainMapping(keyStrings)
+		err = ui.execCommandChainMapping(keyStrings, nil)
 		if err != nil {
-			ui.mainPage.appStatusText.SetText(fmt.Sprintf("[%s]%s[-]", "orange", err.Error()))
+			ui.mainPage.setStatus("StatusWarning", err.Error())
 		}
 		return nil
 	}
 
+	ui.execNormalKey(lhs)
+	if ui.mapDepth > 0 {
+		ui.mapDepth--
+	}
+	return nil
+}
+
+// Run the builtin normal mode action bound to {lhs}
+func (ui *UI) execNormalKey(lhs string) {
+	keepCount := false
 	switch lhs {
+	case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if lhs != "0" || ui.count > 0 {
+			ui.count = ui.count*10 + int(lhs[0]-'0')
+			keepCount = true
+		}
+	case ".":
+		err := ui.repeatLastChange(ui.count)
+		if err != nil {
+			ui.mainPage.setStatus("StatusWarning", err.Error())
+		}
+	case "@":
+		ui.macroRegistry.pending = '@'
+		keepCount = true
+	case "q":
+		if ui.macroRegistry.recording != 0 {
+			ui.stopRecording()
+		} else {
+			ui.macroRegistry.pending = 'q'
+		}
 	case "/", ":", "?":
 		ui.mainPage.commandLine.SetText(lhs)
 		ui.app.SetFocus(ui.mainPage.commandLine)
+	case "<Esc>":
+		ui.clearSelection()
+	case "<Tab>":
+		ui.toggleMark()
+	case "V":
+		ui.toggleVisual()
 	case "<C-d>":
 		ui.movePgDown()
 	case "<C-e>":
@@ -61,15 +116,78 @@ func (ui *UI) listInputHandler(event *tcell.EventKey) *tcell.EventKey {
 	case "n":
 		ui.searchNext()
 	case "z":
-		ui.redrawMid()
+		ui.foldPending = true
+	}
+	if !keepCount {
+		ui.count = 0
+	}
+	if ui.mainPage.selectionFor(ui.mainPage.focusedList).anchor != "" {
+		// Redraw the visual range after moving
+		ui.refreshFocusedList()
+	}
+}
+
+// Handle the register name {reg} following q or @
+func (ui *UI) handleMacroRegister(reg rune) {
+	pending := ui.macroRegistry.pending
+	ui.macroRegistry.pending = 0
+	count := ui.count
+	ui.count = 0
+	var err error
+	switch pending {
+	case 'q':
+		err = ui.startRecording(reg)
+	case '@':
+		err = ui.playMacro(reg, count)
+	}
+	if err != nil {
+		ui.mainPage.setStatus("StatusWarning", err.Error())
+	}
+}
+
+func (ui *UI) listMouseHandler(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	switch action {
+	case tview.MouseLeftDown, tview.MouseMiddleDown, tview.MouseRightDown,
+		tview.MouseScrollUp, tview.MouseScrollDown, tview.MouseScrollLeft, tview.MouseScrollRight:
+	default:
+		return action, event
+	}
+	if !ui.mainPage.streamsCon.InRect(event.Position()) {
+		return action, event
 	}
 	if ui.mapDepth > 0 {
 		ui.mapDepth--
+		return action, event
 	}
-	return nil
+
+	lhs := encodeMappingKey(event)
+	rhs, ok := ui.mapRegistry.mappings[lhs]
+	if !ok {
+		return action, event
+	}
+	keyStrings, err := ui.mapRegistry.resolveMappings(rhs)
+	if err != nil {
+		ui.mainPage.setStatus("StatusWarning", err.Error())
+		return tview.MouseConsumed, nil
+	}
+	ui.mapDepth += len(keyStrings)
+	err = ui.execCommandChainMapping(keyStrings, event)
+	if err != nil {
+		ui.mainPage.setStatus("StatusWarning", err.Error())
+	}
+	return tview.MouseConsumed, nil
 }
 
 func (ui *UI) commandLineInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	if ui.mapDepth == 0 {
+		key := encodeMappingKey(event)
+		if key == "<Tab>" {
+			// Replayed keys run like a mapping, where completion has to be
+			// triggered explicitly
+			key = "<C-z><Tab>"
+		}
+		ui.macroRegistry.recordKey(key)
+	}
 	if ui.mapDepth > 0 {
 		ui.mapDepth--
 		if event.Key() == tcell.KeyCtrlZ {
@@ -81,33 +199,18 @@ func (ui *UI) commandLineInputHandler(event *tcell.EventKey) *tcell.EventKey {
 			ui.mapDepth = oldMapDepth
 			return nil
 		}
-		return event
+		return ui.editCommandLine(event)
+	}
+	if ui.cmdlinePending != 0 {
+		return ui.editCommandLine(event)
 	}
 
 	switch event.Key() {
 	case tcell.KeyUp:
-		if len(ui.cmdRegistry.history) == 0 {
-			return nil
-		}
-		if ui.cmdRegistry.histIndex > 0 {
-			ui.cmdRegistry.histIndex -= 1
-		}
-		cmdLine := ui.cmdRegistry.history[ui.cmdRegistry.histIndex]
-		ui.mainPage.commandLine.SetText(cmdLine)
-		ui.mainPage.commandLine.Autocomplete()
+		ui.browseHistory(false)
 		return nil
 	case tcell.KeyDown:
-		if len(ui.cmdRegistry.history) == 0 {
-			return nil
-		}
-		if ui.cmdRegistry.histIndex < len(ui.cmdRegistry.history)-1 {
-			ui.cmdRegistry.histIndex += 1
-		} else if ui.cmdRegistry.histIndex == len(ui.cmdRegistry.history) {
-			ui.cmdRegistry.histIndex -= 1
-		}
-		cmdLine := ui.cmdRegistry.history[ui.cmdRegistry.histIndex]
-		ui.mainPage.commandLine.SetText(cmdLine)
-		ui.mainPage.commandLine.Autocomplete()
+		ui.browseHistory(true)
 		return nil
 	case tcell.KeyCtrlP:
 		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
@@ -116,9 +219,6 @@ func (ui *UI) commandLineInputHandler(event *tcell.EventKey) *tcell.EventKey {
 	case tcell.KeyCtrlY:
 		// Common vim key for accepting completion window
 		return tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone)
-	case tcell.KeyCtrlE:
-		// Common vim key for dismissing completion window
-		return tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone)
 	}
-	return event
+	return ui.editCommandLine(event)
 }
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+)
+
+type JobState int
+
+const (
+	JobRunning JobState = iota
+	JobExited
+	JobFailed
+	JobKilled
+)
+
+const (
+	maxFinishedJobs = 50
+	stderrTailSize  = 4096
+)
+
+// A process launched to open a stream
+type Job struct {
+	ID       int
+	Name     string
+	Service  string
+	Method   OpenMethod
+	PID      int
+	Started  time.Time
+	Ended    time.Time
+	State    JobState
+	ExitCode int
+	stderr   *tailBuffer
+	cmd      *exec.Cmd
+}
+
+type JobRegistry struct {
+	mu     sync.Mutex
+	jobs   []*Job
+	nextID int
+}
+
+// Keeps the last {size} bytes written to it
+type tailBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{nextID: 1}
+}
+
+func (s JobState) String() string {
+	switch s {
+	case JobRunning:
+		return "running"
+	case JobExited:
+		return "exited"
+	case JobFailed:
+		return "failed"
+	case JobKilled:
+		return "killed"
+	}
+	return "unknown"
+}
+
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.buf = append(tb.buf, p...)
+	if len(tb.buf) > tb.size {
+		tb.buf = tb.buf[len(tb.buf)-tb.size:]
+	}
+	return len(p), nil
+}
+
+// Last non-empty line written
+func (tb *tailBuffer) lastLine() string {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	lines := strings.Split(strings.TrimSpace(string(tb.buf)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// Start {cmd} for {data} and reap it in the background, reporting a non-zero
+// exit in the status window
+func (ui *UI) startJob(cmd *exec.Cmd, data ls.StreamData, method OpenMethod) error {
+	stderr := &tailBuffer{size: stderrTailSize}
+	cmd.Stderr = stderr
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	job := ui.jobRegistry.add(&Job{
+		Name:    data.GetName(),
+		Service: data.GetService(),
+		Method:  method,
+		PID:     cmd.Process.Pid,
+		Started: time.Now(),
+		State:   JobRunning,
+		stderr:  stderr,
+		cmd:     cmd,
+	})
+	ui.refreshPlaying()
+	go func() {
+		err := cmd.Wait()
+		ui.jobRegistry.finish(job, err)
+		ui.app.QueueUpdateDraw(func() {
+			if job.State == JobFailed {
+				msg := fmt.Sprintf("Job %d (%s) exited with status %d", job.ID, job.Name, job.ExitCode)
+				if tail := job.stderr.lastLine(); tail != "" {
+					msg += ": " + tail
+				}
+				ui.mainPage.setStatus("StatusError", tview.Escape(msg))
+			}
+			ui.refreshPlaying()
+		})
+	}()
+	return nil
+}
+
+func (r *JobRegistry) add(job *Job) *Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = r.nextID
+	r.nextID++
+	r.jobs = append(r.jobs, job)
+	// Forget the oldest finished jobs
+	finished := 0
+	for _, j := range r.jobs {
+		if j.State != JobRunning {
+			finished++
+		}
+	}
+	r.jobs = slices.DeleteFunc(r.jobs, func(j *Job) bool {
+		if finished > maxFinishedJobs && j.State != JobRunning {
+			finished--
+			return true
+		}
+		return false
+	})
+	return job
+}
+
+func (r *JobRegistry) finish(job *Job, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.Ended = time.Now()
+	if job.State == JobKilled {
+		return
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		job.ExitCode = exitErr.ExitCode()
+		job.State = JobFailed
+	} else if err != nil {
+		job.ExitCode = -1
+		job.State = JobFailed
+	} else {
+		job.State = JobExited
+	}
+}
+
+func (r *JobRegistry) get(id int) (*Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ix := slices.IndexFunc(r.jobs, func(j *Job) bool {
+		return j.ID == id
+	})
+	if ix == -1 {
+		return nil, fmt.Errorf("no such job: %d", id)
+	}
+	return r.jobs[ix], nil
+}
+
+func (r *JobRegistry) running() []*Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []*Job
+	for _, j := range r.jobs {
+		if j.State == JobRunning {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
+}
+
+func (r *JobRegistry) isPlaying(service, name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.ContainsFunc(r.jobs, func(j *Job) bool {
+		return j.State == JobRunning && j.Service == service && j.Name == name
+	})
+}
+
+// Send SIGTERM, or SIGKILL if {force}, to the process group of {job}
+func (r *JobRegistry) kill(job *Job, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.State != JobRunning {
+		return fmt.Errorf("job %d is not running", job.ID)
+	}
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	err := syscall.Kill(-job.PID, sig)
+	if err != nil {
+		return err
+	}
+	job.State = JobKilled
+	return nil
+}
+
+func (r *JobRegistry) describe(m *MainPage) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []byte
+	for _, j := range slices.Backward(r.jobs) {
+		stateGroup := "StatusOk"
+		switch j.State {
+		case JobFailed:
+			stateGroup = "StatusError"
+		case JobKilled, JobExited:
+			stateGroup = "InfoDim"
+		}
+		jobs = fmt.Appendf(
+			jobs,
+			"%s %s %-7d %s %-8s %s/%s\n",
+			m.hlText("InfoLabel", fmt.Sprintf("%-3d", j.ID)),
+			m.hlText(stateGroup, fmt.Sprintf("%-7s", j.State)),
+			j.PID,
+			j.Started.Format(time.TimeOnly),
+			j.Method,
+			j.Service,
+			tview.Escape(j.Name),
+		)
+		if j.State == JobFailed {
+			jobs = fmt.Appendf(jobs, "    status %d", j.ExitCode)
+			if tail := j.stderr.lastLine(); tail != "" {
+				jobs = fmt.Appendf(jobs, ": %s", tview.Escape(tail))
+			}
+			jobs = append(jobs, '\n')
+		}
+	}
+	return jobs
+}
+
+func (r *JobRegistry) runningIDs() []string {
+	var ids []string
+	for _, j := range r.running() {
+		ids = append(ids, strconv.Itoa(j.ID))
+	}
+	return ids
+}
+
+// Redraw the lists if they mark streams that are playing
+func (ui *UI) refreshPlaying() {
+	if !ui.mainPage.playing {
+		return
+	}
+	ui.mainPage.refreshTwitchList()
+	ui.mainPage.refreshStrimsList()
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"text/template"
+	"unicode"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+)
+
+// A command used to open streams, every argument is a template over the
+// fields from launchTemplateFields
+type Launcher struct {
+	Args   []*template.Template
+	Env    []*template.Template
+	Dir    string
+	Source string
+}
+
+type LauncherRegistry struct {
+	// Method -> service -> launcher, the service "" applies to all services
+	launchers map[OpenMethod]map[string]*Launcher
+}
+
+func NewLauncherRegistry() *LauncherRegistry {
+	return &LauncherRegistry{launchers: make(map[OpenMethod]map[string]*Launcher)}
+}
+
+// Services that can be given to `:launcher`, those of the url templates. The
+// current streams are left out so that the rc file, sourced before the first
+// fetch, reads the same every time
+func (ui *UI) knownServices() []string {
+	services := map[string]bool{"twitch": true}
+	for _, src := range ui.urlTemplates {
+		for service := range src.MethodTemplates {
+			services[service] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(services))
+}
+
+// Split {s} on whitespace that is not inside a {{ }} template action
+func splitTemplateArgs(s string) []string {
+	var args []string
+	var cur strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			depth++
+			cur.WriteString("{{")
+			i++
+		case strings.HasPrefix(s[i:], "}}") && depth > 0:
+			depth--
+			cur.WriteString("}}")
+			i++
+		case depth == 0 && unicode.IsSpace(rune(s[i])):
+			if cur.Len() > 0 {
+				args = append(args, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(s[i])
+		}
+	}
+	if cur.Len() > 0 {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// Parse a launcher definition on the form
+// [-cwd={dir}] [-env={KEY=VALUE}]... {program} [args...]
+func parseLauncher(definition string) (*Launcher, error) {
+	launcher := &Launcher{Source: definition}
+	args := splitTemplateArgs(definition)
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		opt, value, _ := strings.Cut(args[0], "=")
+		switch opt {
+		case "-cwd":
+			launcher.Dir = expandHome(value)
+		case "-env":
+			if !strings.Contains(value, "=") {
+				return nil, fmt.Errorf("expected -env=KEY=VALUE: %s", args[0])
+			}
+			tmpl, err := template.New("env").Parse(value)
+			if err != nil {
+				return nil, err
+			}
+			launcher.Env = append(launcher.Env, tmpl)
+		default:
+			return nil, fmt.Errorf("unknown launcher option: %s", opt)
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, errors.New("launcher needs a program")
+	}
+	for i, arg := range args {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		launcher.Args = append(launcher.Args, tmpl)
+	}
+	return launcher, nil
+}
+
+func (r *LauncherRegistry) set(method OpenMethod, service string, launcher *Launcher) {
+	if r.launchers[method] == nil {
+		r.launchers[method] = make(map[string]*Launcher)
+	}
+	r.launchers[method][service] = launcher
+}
+
+func (r *LauncherRegistry) remove(method OpenMethod, service string) error {
+	if _, ok := r.launchers[method][service]; !ok {
+		return fmt.Errorf("no launcher for %s", strings.TrimSpace(string(method)+" "+service))
+	}
+	delete(r.launchers[method], service)
+	return nil
+}
+
+func (r *LauncherRegistry) lookup(method OpenMethod, service string) *Launcher {
+	if launcher, ok := r.launchers[method][service]; ok {
+		return launcher
+	}
+	return r.launchers[method][""]
+}
+
+func (r *LauncherRegistry) describe(m *MainPage) []byte {
+	var lines []string
+	for method, byService := range r.launchers {
+		for service, launcher := range byService {
+			if service == "" {
+				service = "*"
+			}
+			lines = append(lines, fmt.Sprintf(
+				"%s %-10s %s\n",
+				m.hlText("InfoLabel", fmt.Sprintf("%-8s", method)),
+				service,
+				tview.Escape(launcher.Source),
+			))
+		}
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, ""))
+}
+
+// Fields available in launcher templates, where URL is the resolved URL to
+// open rather than the URL reported by the service
+func launchTemplateFields(data ls.StreamData, method OpenMethod, streamURL *url.URL, winopen bool) map[string]any {
+	fields := streamTemplateFields(data)
+	fields["Method"] = string(method)
+	fields["URL"] = streamURL.String()
+	fields["WinOpen"] = winopen
+	return fields
+}
+
+// Build the command for {launcher}, arguments that expand to an empty
+// string are dropped
+func (launcher *Launcher) command(fields map[string]any) (*exec.Cmd, error) {
+	expand := func(tmpl *template.Template) (string, error) {
+		var buffer bytes.Buffer
+		err := tmpl.Execute(&buffer, fields)
+		return buffer.String(), err
+	}
+	var args []string
+	for _, tmpl := range launcher.Args {
+		arg, err := expand(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		if arg != "" {
+			args = append(args, arg)
+		}
+	}
+	if len(args) == 0 {
+		return nil, errors.New("launcher expanded to an empty command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = launcher.Dir
+	if len(launcher.Env) > 0 {
+		cmd.Env = os.Environ()
+		for _, tmpl := range launcher.Env {
+			kv, err := expand(tmpl)
+			if err != nil {
+				return nil, err
+			}
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	return cmd, nil
+}
+
+func (ui *UI) defaultLaunchCommand(method OpenMethod, streamURL *url.URL) *exec.Cmd {
+	program := ui.getProgram(method)
+	var args []string
+	if ui.mainPage.winopen {
+		switch program {
+		case "brave", "chromium", "firefox", "google-chrome", "opera", "vivaldi":
+			args = append(args, "--new-window")
+		}
+	}
+	args = append(args, streamURL.String())
+	return exec.Command(program, args...)
+}
+
+func (ui *UI) launchCommand(data ls.StreamData, method OpenMethod, streamURL *url.URL) (*exec.Cmd, error) {
+	var cmd *exec.Cmd
+	if launcher := ui.launcherRegistry.lookup(method, data.GetService()); launcher != nil {
+		var err error
+		fields := launchTemplateFields(data, method, streamURL, ui.mainPage.winopen)
+		cmd, err = launcher.command(fields)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		cmd = ui.defaultLaunchCommand(method, streamURL)
+	}
+	// Set the new process process group-ID to its process ID
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Pgid:    0,
+		Setpgid: true,
+	}
+	return cmd, nil
+}
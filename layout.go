@@ -1,15 +1,20 @@
 package main
 
 import (
-	"fmt"
-	"time"
-
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
 func (ui *UI) setupMainPage() {
 	ui.app.EnableMouse(true)
+	ui.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		ui.redraw.touch()
+		return event
+	})
+	ui.app.SetMouseCapture(func(event *tcell.EventMouse, action tview.MouseAction) (*tcell.EventMouse, tview.MouseAction) {
+		ui.redraw.touch()
+		return event, action
+	})
 	ui.mainPage.con.AddItem(ui.mainPage.streamsCon, 0, 1, true)
 	ui.mainPage.con.AddItem(ui.mainPage.infoCon, 0, 2, false)
 	ui.mainPage.con.SetDirection(tview.FlexColumn)
@@ -19,6 +24,7 @@ func (ui *UI) setupMainPage() {
 	// TwitchList
 	ui.mainPage.streamsCon.AddItem(ui.mainPage.twitchList, 0, 1, true)
 	ui.mainPage.streamsCon.SetInputCapture(ui.listInputHandler)
+	ui.mainPage.streamsCon.SetMouseCapture(ui.listMouseHandler)
 	ui.mainPage.twitchList.SetChangedFunc(ui.mainPage.updateTwitchStreamInfo)
 	ui.mainPage.twitchList.SetBackgroundColor(tcell.ColorDefault)
 	ui.mainPage.twitchList.SetBorder(true)
@@ -38,7 +44,6 @@ func (ui *UI) setupMainPage() {
 	// ErrorInfo
 	ui.mainPage.infoCon.AddItem(ui.mainPage.appStatusText, 3, 0, false)
 	ui.mainPage.appStatusText.SetBackgroundColor(tcell.ColorDefault)
-	ui.mainPage.appStatusText.SetTitle("Status (" + ui.addr.Redacted() + ")")
 	ui.mainPage.appStatusText.SetBorder(true)
 	ui.mainPage.appStatusText.SetDynamicColors(true)
 	ui.mainPage.appStatusText.SetTextAlign(tview.AlignCenter)
@@ -51,32 +56,17 @@ func (ui *UI) setupMainPage() {
 	// CommandRow
 	ui.mainPage.infoCon.AddItem(ui.mainPage.commandRow, 1, 0, false)
 	ui.mainPage.commandRow.AddItem(ui.mainPage.commandLine, 0, 1, true)
-	ui.mainPage.commandRow.AddItem(ui.mainPage.fetchTimeView, 26, 0, false)
+	// Sized to its text by updateStatusViews
+	ui.mainPage.commandRow.AddItem(ui.mainPage.fetchTimeView, 0, 0, false)
 	// CommandLine
 	ui.mainPage.commandLine.SetText("Please see `:help` or `:map`!")
-	ui.mainPage.commandLine.SetFieldBackgroundColor(tcell.ColorBlack)
 	ui.mainPage.commandLine.SetChangedFunc(ui.onTypeCommandChain)
 	ui.mainPage.commandLine.SetFinishedFunc(ui.execCommandChainCallback)
 	ui.mainPage.commandLine.SetInputCapture(ui.commandLineInputHandler)
 	ui.mainPage.commandLine.SetAutocompletedFunc(ui.commandLineCompleteDone)
 	ui.mainPage.commandLine.SetAutocompleteFunc(ui.commandLineComplete)
 	// Fetch time view
-	ui.mainPage.fetchTimeView.SetBackgroundColor(tcell.ColorOrange)
-	ui.mainPage.fetchTimeView.SetTextColor(tcell.ColorBlack)
-	ui.mainPage.fetchTimeView.SetText("No timing data ")
 	ui.mainPage.fetchTimeView.SetTextAlign(tview.AlignRight)
-	ui.mainPage.fetchTimeView.SetDrawFunc(ui.updateLastFetched)
-}
-
-func (ui *UI) updateLastFetched(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
-	if ui.fetchMeta != nil && !ui.fetchMeta.LastModified.IsZero() {
-		ui.mainPage.fetchTimeView.Clear()
-		_, _ = ui.mainPage.fetchTimeView.Write(fmt.Appendf(
-			nil,
-			"%s (update in %.0fs) ",
-			ui.fetchMeta.LastModified.In(time.Local).Format(time.TimeOnly),
-			time.Until(ui.fetchMeta.LastModified.Add(ui.fetchMeta.RefreshInterval)).Seconds(),
-		))
-	}
-	return x, y, width, height
+	ui.app.SetBeforeDrawFunc(ui.updateStatusViews)
+	ui.applyHighlights()
 }
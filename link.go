@@ -3,20 +3,23 @@ package main
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"maps"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"slices"
+	"sort"
 	"strings"
-	"syscall"
 	"text/template"
 
 	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
 )
 
-type OpenMethod int
+type OpenMethod string
 
 type URLTemplateSource struct {
 	MethodTemplates map[string]URLTemplates
@@ -33,13 +36,15 @@ type URLTemplates struct {
 type Values map[string]string // simpler, pseudo url.Values helper struct
 
 const (
-	lnkOpenEmbed OpenMethod = iota
-	lnkOpenHomePage
-	lnkOpenMpv
-	lnkOpenStrims
-	lnkOpenChat
+	lnkOpenEmbed    OpenMethod = "embed"
+	lnkOpenHomePage OpenMethod = "homepage"
+	lnkOpenMpv      OpenMethod = "mpv"
+	lnkOpenStrims   OpenMethod = "strims"
+	lnkOpenChat     OpenMethod = "chat"
 )
 
+// Built-in url templates, user templates are added on top of a copy of these
+// with `:urltemplate`
 var urlBuilders = map[OpenMethod]URLTemplateSource{
 	lnkOpenEmbed: {MethodTemplates: map[string]URLTemplates{
 		"angelthump": {Host: "player.angelthump.com", Query: Values{"channel": "{{.NameI}}"}},
@@ -73,43 +78,156 @@ var urlBuilders = map[OpenMethod]URLTemplateSource{
 	},
 }
 
+func NewURLTemplateRegistry() map[OpenMethod]URLTemplateSource {
+	templates := make(map[OpenMethod]URLTemplateSource, len(urlBuilders))
+	for method, src := range urlBuilders {
+		templates[method] = URLTemplateSource{
+			MethodTemplates: maps.Clone(src.MethodTemplates),
+			DefaultTemplate: src.DefaultTemplate,
+		}
+	}
+	return templates
+}
+
+func (ui *UI) openMethods() []string {
+	methods := make([]string, 0, len(ui.urlTemplates))
+	for method := range ui.urlTemplates {
+		methods = append(methods, string(method))
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func (ui *UI) parseOpenMethod(name string) (OpenMethod, error) {
+	if _, ok := ui.urlTemplates[OpenMethod(name)]; !ok {
+		return "", fmt.Errorf("unsupported method: %s", name)
+	}
+	return OpenMethod(name), nil
+}
+
+// Set the url template for {method} and {service}, where the service "*"
+// sets the fallback for services without their own template. New methods are
+// created as needed
+func (ui *UI) setURLTemplate(method OpenMethod, service string, rawURL string) error {
+	ut := URLTemplates{RawURL: rawURL}
+	err := ut.validate(service)
+	if err != nil {
+		return err
+	}
+	src := ui.urlTemplates[method]
+	if service == "*" {
+		src.DefaultTemplate = &ut
+	} else {
+		if src.MethodTemplates == nil {
+			src.MethodTemplates = make(map[string]URLTemplates)
+		}
+		src.MethodTemplates[service] = ut
+	}
+	ui.urlTemplates[method] = src
+	return nil
+}
+
+func (ui *UI) removeURLTemplate(method OpenMethod, service string) error {
+	src, ok := ui.urlTemplates[method]
+	if !ok {
+		return fmt.Errorf("unsupported method: %s", method)
+	}
+	if service == "*" {
+		if src.DefaultTemplate == nil {
+			return fmt.Errorf("no default template for %s", method)
+		}
+		src.DefaultTemplate = nil
+	} else {
+		if _, ok := src.MethodTemplates[service]; !ok {
+			return fmt.Errorf("no template for %s %s", method, service)
+		}
+		delete(src.MethodTemplates, service)
+	}
+	if src.DefaultTemplate == nil && len(src.MethodTemplates) == 0 {
+		delete(ui.urlTemplates, method)
+	} else {
+		ui.urlTemplates[method] = src
+	}
+	return nil
+}
+
+func (ui *UI) describeURLTemplates(method string) []byte {
+	var lines []string
+	for m, src := range ui.urlTemplates {
+		if method != "" && string(m) != method {
+			continue
+		}
+		label := ui.mainPage.hlText("InfoLabel", fmt.Sprintf("%-8s", m))
+		for service, ut := range src.MethodTemplates {
+			lines = append(lines, fmt.Sprintf("%s %-10s %s\n", label, service, tview.Escape(ut.String())))
+		}
+		if src.DefaultTemplate != nil {
+			lines = append(lines, fmt.Sprintf("%s %-10s %s\n", label, "*", tview.Escape(src.DefaultTemplate.String())))
+		}
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, ""))
+}
+
 func (ui *UI) openSelectedStream(method OpenMethod) error {
-	data, err := ui.getSelectedStreamData()
+	streams, err := ui.getSelectedStreams()
 	if err != nil {
 		return err
 	}
-	url, err := streamToURL(data, method)
+	for _, data := range streams {
+		err = ui.openStream(data, method)
+		if err != nil {
+			return err
+		}
+	}
+	ui.clearSelection()
+	return nil
+}
+
+func (ui *UI) openStream(data ls.StreamData, method OpenMethod) error {
+	url, err := ui.streamToURL(data, method)
 	if err != nil {
 		return err
 	}
-	program := ui.getProgram(method)
-	var args []string
-	if ui.mainPage.winopen {
-		switch program {
-		case "brave", "chromium", "firefox", "google-chrome", "opera", "vivaldi":
-			args = append(args, "--new-window")
+	if method == lnkOpenMpv && ui.mainPage.mpvipc {
+		err = ui.mpvLoad(data, url.String(), "replace")
+	} else {
+		var cmd *exec.Cmd
+		cmd, err = ui.launchCommand(data, method, url)
+		if err == nil {
+			err = ui.startJob(cmd, data, method)
 		}
 	}
-	args = append(args, url.String())
-	cmd := exec.Command(program, args...)
-	// Set the new process process group-ID to its process ID
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Pgid:    0,
-		Setpgid: true,
+	if err != nil {
+		return err
+	}
+	err = appendWatchLog(newWatchEntry(data, method))
+	if err != nil {
+		return fmt.Errorf("writing watch history: %w", err)
 	}
-	return cmd.Start()
+	return nil
 }
 
 func (ui *UI) copySelectedStreamToClipboard(method OpenMethod) error {
-	data, err := ui.getSelectedStreamData()
+	streams, err := ui.getSelectedStreams()
 	if err != nil {
 		return err
 	}
-	url, err := streamToURL(data, method)
+	urls := make([]string, 0, len(streams))
+	for _, data := range streams {
+		url, err := ui.streamToURL(data, method)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, url.String())
+	}
+	backend, err := copyToClipboard(ui.mainPage.clipboard, strings.Join(urls, "\n"))
 	if err != nil {
-		return err
+		return fmt.Errorf("copying with %s: %w", backend, err)
 	}
-	return exec.Command("wl-copy", url.String()).Run()
+	ui.mainPage.setStatus("StatusOk", fmt.Sprintf("Copied %d %s url(s) using %s", len(urls), method, backend))
+	ui.clearSelection()
+	return nil
 }
 
 func (ui *UI) getSelectedStreamData() (ls.StreamData, error) {
@@ -117,28 +235,17 @@ func (ui *UI) getSelectedStreamData() (ls.StreamData, error) {
 	if listIdx >= ui.mainPage.focusedList.GetItemCount() {
 		return nil, errors.New("current selection out of bounds")
 	}
-	primaryText, _ := ui.mainPage.focusedList.GetItemText(listIdx)
-	switch ui.mainPage.focusedList {
-	case ui.mainPage.twitchList:
-		if ui.mainPage.twitchFilter.indexMapping == nil {
-			break
-		}
-		tviewIx := ui.mainPage.twitchList.GetCurrentItem()
-		ix := ui.mainPage.twitchFilter.indexMapping[tviewIx]
-		return &ui.mainPage.streams.Twitch.Data[ix], nil
-	case ui.mainPage.strimsList:
-		ix := slices.IndexFunc(ui.mainPage.streams.Strims.Data, func(sd ls.StrimsStreamData) bool {
-			return sd.Channel == primaryText
-		})
-		if ix != -1 {
-			return &ui.mainPage.streams.Strims.Data[ix], nil
-		}
+	if data := ui.mainPage.streamAt(ui.mainPage.focusedList, listIdx); data != nil {
+		return data, nil
+	}
+	if ui.mainPage.filterFor(ui.mainPage.focusedList).rowGroups != nil {
+		return nil, errors.New("not a stream, use za to fold the group")
 	}
 	return nil, errors.New("cannot open empty result")
 }
 
-func streamToURL(data ls.StreamData, method OpenMethod) (*url.URL, error) {
-	tmplSrc, ok := urlBuilders[method]
+func (ui *UI) streamToURL(data ls.StreamData, method OpenMethod) (*url.URL, error) {
+	tmplSrc, ok := ui.urlTemplates[method]
 	if !ok {
 		return nil, errors.New("unsupported method")
 	}
@@ -187,17 +294,86 @@ func (ut *URLTemplates) apply(data ls.StreamData) (*url.URL, error) {
 	return url, nil
 }
 
+func (ut *URLTemplates) String() string {
+	if ut.RawURL != "" {
+		return ut.RawURL
+	}
+	var b strings.Builder
+	b.WriteString("https://" + ut.Host)
+	if ut.Path != "" {
+		b.WriteString("/" + ut.Path)
+	}
+	keys := slices.Sorted(maps.Keys(ut.Query))
+	for i, key := range keys {
+		if i == 0 {
+			b.WriteString("?")
+		} else {
+			b.WriteString("&")
+		}
+		b.WriteString(key + "=" + ut.Query[key])
+	}
+	return b.String()
+}
+
+// Check that the template parses and expands into a valid absolute URL for
+// a sample stream on {service}
+func (ut *URLTemplates) validate(service string) error {
+	var sample ls.StreamData = &ls.StrimsStreamData{
+		Channel:  "Name",
+		Rustlers: 1,
+		Service:  service,
+		Title:    "Title",
+		URL:      "https://example.com/stream.m3u8",
+	}
+	if service == "twitch" {
+		sample = &ls.TwitchStreamData{
+			GameName:    "Game",
+			Title:       "Title",
+			UserName:    "Name",
+			ViewerCount: 1,
+		}
+	}
+	u, err := ut.apply(sample)
+	if err != nil {
+		return err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("template does not expand to an absolute url: %s", u)
+	}
+	return nil
+}
+
+// Fields available in url templates
+func streamTemplateFields(data ls.StreamData) map[string]any {
+	fields := map[string]any{
+		"Name":    data.GetName(),
+		"NameI":   strings.ToLower(data.GetName()),
+		"Service": data.GetService(),
+		"Title":   "",
+		"Game":    "",
+		"URL":     "",
+		"Viewers": 0,
+	}
+	switch sd := data.(type) {
+	case *ls.TwitchStreamData:
+		fields["Title"] = sd.Title
+		fields["Game"] = sd.GameName
+		fields["Viewers"] = sd.ViewerCount
+	case *ls.StrimsStreamData:
+		fields["Title"] = sd.Title
+		fields["URL"] = sd.URL
+		fields["Viewers"] = sd.Rustlers
+	}
+	return fields
+}
+
 func executeTemplateString(templateString string, data ls.StreamData) (string, error) {
-	tmpl, err := template.New("t").Parse(templateString)
+	tmpl, err := template.New("t").Option("missingkey=error").Parse(templateString)
 	if err != nil {
 		return "", err
 	}
 	var buffer bytes.Buffer
-	err = tmpl.Execute(&buffer, map[string]string{
-		"NameI":   strings.ToLower(data.GetName()),
-		"Name":    data.GetName(),
-		"Service": data.GetService(),
-	})
+	err = tmpl.Execute(&buffer, streamTemplateFields(data))
 	return buffer.String(), err
 }
 
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"regexp"
+)
+
+// Text of a list item as given to tview, or its cells in the table list mode
+type listRow struct {
+	key       string // Identity of the row, see rowKey
+	main      string
+	secondary string
+	cells     []string
+}
+
+// The case insensitive regexp for {filter}, compiled again only when the
+// filter changes
+func (f *FilterInput) regexp(filter string) (*regexp.Regexp, error) {
+	if f.compiledInput != filter || (f.compiled == nil && f.compileErr == nil) {
+		f.compiled, f.compileErr = regexp.Compile(`(?i)` + filter)
+		f.compiledInput = filter
+	}
+	return f.compiled, f.compileErr
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type MacroRegistry struct {
+	registers  map[string]string
+	keys       []string
+	recording  rune // Register being recorded into, 0 if not recording
+	pending    rune // 'q' or '@' while waiting for a register name
+	lastPlayed rune
+}
+
+func NewMacroRegistry() *MacroRegistry {
+	return &MacroRegistry{registers: make(map[string]string)}
+}
+
+func isMacroRegister(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// Record a key typed by the user (not one queued by a mapping) if a macro is
+// being recorded
+func (r *MacroRegistry) recordKey(key string) {
+	if r.recording == 0 {
+		return
+	}
+	r.keys = append(r.keys, key)
+}
+
+func (ui *UI) startRecording(reg rune) error {
+	if !isMacroRegister(reg) {
+		return fmt.Errorf("invalid register: %c", reg)
+	}
+	mr := ui.macroRegistry
+	mr.recording = reg
+	mr.keys = nil
+	ui.mainPage.setStatus("StatusWarning", fmt.Sprintf("recording @%c", reg))
+	return nil
+}
+
+func (ui *UI) stopRecording() {
+	mr := ui.macroRegistry
+	keys := mr.keys
+	// Drop the q that stopped the recording if it was typed
+	if ui.mapDepth == 0 && len(keys) > 0 && keys[len(keys)-1] == "q" {
+		keys = keys[:len(keys)-1]
+	}
+	name := string(mr.recording)
+	if mr.recording >= 'A' && mr.recording <= 'Z' {
+		name = strings.ToLower(name)
+		mr.registers[name] += strings.Join(keys, "")
+	} else {
+		mr.registers[name] = strings.Join(keys, "")
+	}
+	ui.mainPage.setStatus("StatusOk", fmt.Sprintf("recorded @%s", name))
+	mr.recording = 0
+	mr.keys = nil
+}
+
+// Replay register {reg} {count} times, @ replays the last played register
+func (ui *UI) playMacro(reg rune, count int) error {
+	mr := ui.macroRegistry
+	if reg == '@' {
+		if mr.lastPlayed == 0 {
+			return fmt.Errorf("no previously used register")
+		}
+		reg = mr.lastPlayed
+	}
+	if !isMacroRegister(reg) {
+		return fmt.Errorf("invalid register: %c", reg)
+	}
+	name := strings.ToLower(string(reg))
+	keys, ok := mr.registers[name]
+	if !ok || keys == "" {
+		return fmt.Errorf("register %s is empty", name)
+	}
+	mr.lastPlayed = reg
+	if count < 1 {
+		count = 1
+	}
+	keyStrings, err := ui.mapRegistry.resolveMappings(strings.Repeat(keys, count))
+	if err != nil {
+		return err
+	}
+	ui.mapDepth += len(keyStrings)
+	return ui.execCommandChainMapping(keyStrings, nil)
+}
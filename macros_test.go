@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Record {keys} into register {reg} the way typed keys are recorded
+func recordMacro(ui *UI, reg rune, keys ...string) {
+	ui.startRecording(reg)
+	for _, key := range keys {
+		ui.macroRegistry.recordKey(key)
+	}
+	ui.stopRecording()
+}
+
+func TestMacroThroughCommandLineMapping(t *testing.T) {
+	ui := NewUI()
+	ui.setupMainPage()
+	tests := []struct {
+		typed []string
+		want  string
+	}{{
+		// <Space> opens the command line with :open<Space>
+		typed: []string{"<Space>", "m", "p", "v", "<CR>"},
+		want:  ":open<Space>mpv<CR>",
+	}, {
+		// f opens the command line with :global, the s of speedrun is not
+		// the s mapping
+		typed: []string{"f", "s", "p", "e", "e", "d", "r", "u", "n", "/", "p", "<CR>"},
+		want:  ":global <C-z><Tab>speedrun/p<CR>",
+	}, {
+		// Back in normal mode after <CR>, so the u after it is mapped again
+		typed: []string{"<Space>", "c", "h", "a", "t", "<CR>", "u"},
+		want:  ":open<Space>chat<CR>:undo<CR>",
+	}}
+	for _, tt := range tests {
+		recordMacro(ui, 'a', tt.typed...)
+		keys, err := ui.mapRegistry.resolveMappings(ui.macroRegistry.registers["a"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(keys, ""); got != tt.want {
+			t.Errorf("replaying %v gave %s, want %s", tt.typed, got, tt.want)
+		}
+	}
+}
+
+func TestResolveMappingsNested(t *testing.T) {
+	r := &MappingRegistry{mappings: map[string]string{
+		"a": ":echo a<CR>",
+		"b": "a:echo ",
+		"x": ":quit<CR>",
+	}}
+	keys, err := r.resolveMappings("bx<CR>x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		":", "e", "c", "h", "o", " ", "a", "<CR>",
+		":", "e", "c", "h", "o", " ", "x", "<CR>",
+		":", "q", "u", "i", "t", "<CR>",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %q, want %q", keys, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -24,27 +25,27 @@ var defaultMappingLiterals = map[string]string{
 	"<C-f>":   ":scrollinfo down<CR>",
 	"<C-l>":   ":nohlsearch<CR>",
 	"<C-w>":   ":focus toggle<CR>",
-	"<CR>":    "lq",
+	"<CR>":    "lQ",
 	"<F1>":    ":echo Please see `:help` or `:map`!<CR>",
-	"<Right>": "lq",
+	"<Right>": "lQ",
 	"<Space>": ":open<Space>",
+	"Q":       ":quit<CR>",
 	"R":       ":update<CR>r",
 	"U":       ":windo undo<CR>",
 	"W":       ":set! winopen<CR>",
 	"b":       "lc",
-	"c":       ":set winopen | open chat<CR>q",
+	"c":       ":set winopen | open chat<CR>Q",
 	"f":       ":global <C-z><Tab>",
 	"h":       "<F1>",
 	"l":       ":open embed<CR>",
-	"m":       ":open mpv<CR>q",
+	"m":       ":open mpv<CR>Q",
 	"o":       "<C-w>",
-	"q":       ":quit<CR>",
 	"r":       ":sync<CR>",
-	"s":       ":open strims<CR>q",
+	"s":       ":open strims<CR>Q",
 	"t":       ":set! strims | focus twitch<CR>",
 	"u":       ":undo<CR>",
 	"v":       ":vglobal <C-z><Tab>",
-	"w":       ":open homepage<CR>q",
+	"w":       ":open homepage<CR>Q",
 	"y":       ":copyurl<Space>",
 }
 
@@ -52,88 +53,125 @@ func NewMappingRegistry() *MappingRegistry {
 	return &MappingRegistry{mappings: defaultMappingLiterals}
 }
 
-func validateMappingKey(input string) error {
-	_, err := parseMappingKey(input)
-	return err
+var namedKeys = map[tcell.Key]string{
+	tcell.KeyBackspace: "BS",
+	tcell.KeyBacktab:   "S-Tab",
+	tcell.KeyCtrlSpace: "C-Space",
+	tcell.KeyDelete:    "Del",
+	tcell.KeyDown:      "Down",
+	tcell.KeyEnd:       "End",
+	tcell.KeyEnter:     "CR",
+	tcell.KeyEsc:       "Esc",
+	tcell.KeyHome:      "Home",
+	tcell.KeyInsert:    "Insert",
+	tcell.KeyLeft:      "Left",
+	tcell.KeyPgDn:      "PageDown",
+	tcell.KeyPgUp:      "PageUp",
+	tcell.KeyRight:     "Right",
+	tcell.KeyTab:       "Tab",
+	tcell.KeyUp:        "Up",
 }
 
-func encodeMappingKey(input *tcell.EventKey) string {
-	switch input.Key() {
-	case tcell.KeyRune:
-		switch input.Rune() {
-		case ' ':
-			return "<Space>"
-		default:
-			return string(input.Rune())
-		}
-	case tcell.KeyDown:
-		return "<Down>"
-	case tcell.KeyEnter:
-		return "<CR>"
-	case tcell.KeyEsc:
-		return "<Esc>"
-	case tcell.KeyLeft:
-		return "<Left>"
-	case tcell.KeyRight:
-		return "<Right>"
-	case tcell.KeyTab:
-		return "<Tab>"
-	case tcell.KeyUp:
-		return "<Up>"
-	}
-	if input.Key() >= tcell.KeyCtrlA && input.Key() <= tcell.KeyCtrlZ {
-		c := 'a' + rune(input.Key()-tcell.KeyCtrlA)
-		return fmt.Sprintf("<C-%c>", c)
-	}
-	if input.Key() >= tcell.KeyF1 && input.Key() <= tcell.KeyF12 {
-		fnum := int(input.Key() - tcell.KeyF1 + 1)
-		return fmt.Sprintf("<F%d>", fnum)
-	}
-	return fmt.Sprintf("<Key-%d>", input.Key())
+var namedRunes = map[rune]string{
+	' ': "Space",
+	'<': "lt",
 }
 
-func parseMappingKey(key string) (*tcell.EventKey, error) {
-	if key != " " {
-		key = strings.TrimSpace(key)
+var namedButtons = map[tcell.ButtonMask]string{
+	tcell.ButtonNone:      "MouseMove",
+	tcell.ButtonPrimary:   "LeftMouse",
+	tcell.ButtonMiddle:    "MiddleMouse",
+	tcell.ButtonSecondary: "RightMouse",
+	tcell.Button4:         "X1Mouse",
+	tcell.Button5:         "X2Mouse",
+	tcell.WheelDown:       "ScrollWheelDown",
+	tcell.WheelLeft:       "ScrollWheelLeft",
+	tcell.WheelRight:      "ScrollWheelRight",
+	tcell.WheelUp:         "ScrollWheelUp",
+}
+
+// Modifier prefixes in the order they are encoded, M- is accepted as an
+// alias for A- when parsing
+var modifierPrefixes = []struct {
+	prefix string
+	mod    tcell.ModMask
+}{
+	{"C-", tcell.ModCtrl},
+	{"S-", tcell.ModShift},
+	{"A-", tcell.ModAlt},
+}
+
+func encodeModifiers(mod tcell.ModMask) string {
+	if mod&tcell.ModMeta != 0 {
+		mod |= tcell.ModAlt
 	}
-	if strings.HasPrefix(key, "<") && strings.HasSuffix(key, ">") {
-		name := key[1 : len(key)-1]
-		switch name {
-		case "CR":
-			return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), nil
-		case "Down":
-			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), nil
-		case "Esc":
-			return tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), nil
-		case "Left":
-			return tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), nil
-		case "Right":
-			return tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone), nil
-		case "Space":
-			return tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), nil
-		case "Tab":
-			return tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), nil
-		case "Up":
-			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone), nil
-		}
-		if strings.HasPrefix(name, "C-") && len(name) == 3 {
-			c := rune(name[2])
-			if c >= 'a' && c <= 'z' {
-				ctrlKey := tcell.KeyCtrlA + tcell.Key(c-'a')
-				return tcell.NewEventKey(ctrlKey, 0, tcell.ModNone), nil
-			}
+	var b strings.Builder
+	for _, mp := range modifierPrefixes {
+		if mod&mp.mod != 0 {
+			b.WriteString(mp.prefix)
 		}
-		if strings.HasPrefix(name, "F") {
-			c, err := strconv.Atoi(name[1:])
-			if err != nil {
-				return nil, fmt.Errorf("bad function key: F%s", name[1:])
-			}
-			if c >= 1 && c <= 12 {
-				ctrlKey := tcell.KeyF1 + tcell.Key(c-1)
-				return tcell.NewEventKey(ctrlKey, 0, tcell.ModNone), nil
+	}
+	return b.String()
+}
+
+func encodeMappingKey(input tcell.Event) string {
+	switch ev := input.(type) {
+	case *tcell.EventKey:
+		return encodeKeyEvent(ev)
+	case *tcell.EventMouse:
+		name, ok := namedButtons[ev.Buttons()]
+		if !ok {
+			name = fmt.Sprintf("Mouse-%d", ev.Buttons())
+		}
+		return "<" + encodeModifiers(ev.Modifiers()) + name + ">"
+	}
+	return ""
+}
+
+func encodeKeyEvent(input *tcell.EventKey) string {
+	mod := input.Modifiers()
+	var name string
+	switch {
+	case input.Key() == tcell.KeyRune:
+		// The case of the rune already carries shift
+		mod &^= tcell.ModShift
+		r := input.Rune()
+		if named, ok := namedRunes[r]; ok {
+			name = named
+		} else if mod == tcell.ModNone {
+			return string(r)
+		} else {
+			name = string(r)
+		}
+	case input.Key() >= tcell.KeyCtrlA && input.Key() <= tcell.KeyCtrlZ:
+		mod |= tcell.ModCtrl
+		name = string('a' + rune(input.Key()-tcell.KeyCtrlA))
+	case input.Key() >= tcell.KeyF1 && input.Key() <= tcell.KeyF24:
+		name = fmt.Sprintf("F%d", int(input.Key()-tcell.KeyF1+1))
+	default:
+		named, ok := namedKeys[input.Key()]
+		if !ok {
+			return fmt.Sprintf("<Key-%d>", input.Key())
+		}
+		// Named keys such as C-Space and S-Tab carry their own modifier
+		if pfx, _, found := strings.Cut(named, "-"); found {
+			for _, mp := range modifierPrefixes {
+				if mp.prefix == pfx+"-" {
+					mod &^= mp.mod
+				}
 			}
 		}
-		return nil, fmt.Errorf("unknown key: %s", key)
+		name = named
+	}
+	return "<" + encodeModifiers(mod) + name + ">"
+}
+
+func parseMappingKey(key string) (tcell.Event, error) {
+	if key != " " {
+		key = strings.TrimSpace(key)
+	}
+	if strings.HasPrefix(key, "<") && strings.HasSuffix(key, ">") && len(key) > 2 {
+		return parseSpecialKey(key)
 	}
 	if len([]rune(key)) == 1 {
 		return tcell.NewEventKey(tcell.KeyRune, []rune(key)[0], tcell.ModNone), nil
@@ -141,14 +179,108 @@ func parseMappingKey(key string) (*tcell.EventKey, error) {
 	return nil, fmt.Errorf("invalid key format: %s", key)
 }
 
+func parseSpecialKey(key string) (tcell.Event, error) {
+	name := key[1 : len(key)-1]
+	for k, named := range namedKeys {
+		if name == named {
+			return tcell.NewEventKey(k, 0, tcell.ModNone), nil
+		}
+	}
+	if codeStr, ok := strings.CutPrefix(name, "Key-"); ok {
+		// KeyRune needs a rune to mean anything, so it has no code of its own
+		code, err := strconv.ParseInt(codeStr, 10, 16)
+		if err != nil || tcell.Key(code) == tcell.KeyRune {
+			return nil, fmt.Errorf("bad key code: %s", codeStr)
+		}
+		return tcell.NewEventKey(tcell.Key(code), 0, tcell.ModNone), nil
+	}
+
+	mod := tcell.ModNone
+	for {
+		pfx, rest, found := strings.Cut(name, "-")
+		if !found || rest == "" {
+			break
+		}
+		switch pfx {
+		case "C", "c":
+			mod |= tcell.ModCtrl
+		case "S", "s":
+			mod |= tcell.ModShift
+		case "A", "a", "M", "m":
+			mod |= tcell.ModAlt
+		default:
+			return nil, fmt.Errorf("unknown modifier %s- in key: %s", pfx, key)
+		}
+		name = rest
+	}
+
+	for btn, named := range namedButtons {
+		if name == named {
+			return tcell.NewEventMouse(0, 0, btn, mod), nil
+		}
+	}
+	for k, named := range namedKeys {
+		if name == named {
+			return tcell.NewEventKey(k, 0, mod), nil
+		}
+	}
+	for r, named := range namedRunes {
+		if name == named {
+			return tcell.NewEventKey(tcell.KeyRune, r, mod), nil
+		}
+	}
+	if fnum, ok := strings.CutPrefix(name, "F"); ok && fnum != "" {
+		c, err := strconv.Atoi(fnum)
+		if err != nil {
+			return nil, fmt.Errorf("bad function key: F%s", fnum)
+		}
+		if c < 1 || c > 24 {
+			return nil, fmt.Errorf("function key out of range: F%d", c)
+		}
+		return tcell.NewEventKey(tcell.KeyF1+tcell.Key(c-1), 0, mod), nil
+	}
+	if runes := []rune(name); len(runes) == 1 && mod != tcell.ModNone {
+		r := runes[0]
+		if mod&tcell.ModCtrl != 0 {
+			// Like vim, <C-A> and <C-a> are the same key
+			lower := unicode.ToLower(r)
+			if lower >= 'a' && lower <= 'z' {
+				return tcell.NewEventKey(tcell.KeyCtrlA+tcell.Key(lower-'a'), 0, mod), nil
+			}
+		}
+		if mod&tcell.ModShift != 0 {
+			r = unicode.ToUpper(r)
+			mod &^= tcell.ModShift
+		}
+		return tcell.NewEventKey(tcell.KeyRune, r, mod), nil
+	}
+	return nil, fmt.Errorf("unknown key: %s", key)
+}
+
+// Normalize {key} into the form used to look up mappings, so that
+// e.g. <M-x> and <A-x> refer to the same mapping
+func canonicalMappingKey(key string) (string, error) {
+	ev, err := parseMappingKey(key)
+	if err != nil {
+		return "", err
+	}
+	return encodeMappingKey(ev), nil
+}
+
 func (r *MappingRegistry) resolveMappings(input string) ([]string, error) {
-	mode := ModeNormal
+	keys, _, err := r.resolveFrom(input, ModeNormal)
+	return keys, err
+}
 
+// Resolve the mappings of {input} typed in {mode}, returning the mode it ends
+// in so that a mapping that opens a command line leaves the keys after it in
+// that command line
+func (r *MappingRegistry) resolveFrom(input string, mode Mode) ([]string, Mode, error) {
 	var keys []string
 	for i := 0; i < len(input); {
-		if input[i] == ':' {
+		if mode == ModeNormal && strings.ContainsRune(":/?", rune(input[i])) {
 			mode = ModeCommand
-			keys = append(keys, ":")
+			keys = append(keys, input[i:i+1])
 			i++
 			continue
 		}
@@ -156,10 +288,9 @@ func (r *MappingRegistry) resolveMappings(input string) ([]string, error) {
 		if input[i] == '<' {
 			end := strings.IndexRune(input[i:], '>')
 			if end != -1 {
-				key := input[i : i+end+1]
-				err := validateMappingKey(key)
+				key, err := canonicalMappingKey(input[i : i+end+1])
 				if err != nil {
-					return nil, err
+					return nil, mode, err
 				}
 				switch mode {
 				case ModeCommand:
@@ -171,9 +302,10 @@ func (r *MappingRegistry) resolveMappings(input string) ([]string, error) {
 				case ModeNormal:
 					rhs, ok := r.mappings[key]
 					if ok {
-						rKeys, err := r.resolveMappings(rhs)
+						var rKeys []string
+						rKeys, mode, err = r.resolveFrom(rhs, mode)
 						if err != nil {
-							return nil, err
+							return nil, mode, err
 						}
 						keys = append(keys, rKeys...)
 					} else {
@@ -183,7 +315,7 @@ func (r *MappingRegistry) resolveMappings(input string) ([]string, error) {
 				i += end + 1
 				continue
 			} else {
-				return nil, fmt.Errorf("unmatched < in mapping at position %d", i)
+				return nil, mode, fmt.Errorf("unmatched < in mapping at position %d", i)
 			}
 		}
 
@@ -193,10 +325,11 @@ func (r *MappingRegistry) resolveMappings(input string) ([]string, error) {
 		case ModeNormal:
 			rhs, ok := r.mappings[input[i:i+1]]
 			if ok {
-				rKeys, err := r.resolveMappings(rhs)
+				rKeys, endMode, err := r.resolveFrom(rhs, mode)
 				if err != nil {
-					return nil, err
+					return nil, mode, err
 				}
+				mode = endMode
 				keys = append(keys, rKeys...)
 			} else {
 				keys = append(keys, input[i:i+1])
@@ -204,5 +337,5 @@ func (r *MappingRegistry) resolveMappings(input string) ([]string, error) {
 		}
 		i++
 	}
-	return keys, nil
+	return keys, mode, nil
 }
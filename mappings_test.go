@@ -0,0 +1,95 @@
+package main
+
+import "testing"
+
+func TestCanonicalMappingKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a", "a"},
+		{" ", "<Space>"},
+		{"<", "<lt>"},
+		{"<lt>", "<lt>"},
+		{"<A-x>", "<A-x>"},
+		{"<M-x>", "<A-x>"},
+		{"<m-x>", "<A-x>"},
+		{"<A-<>", "<A-lt>"},
+		{"<S-a>", "A"},
+		{"<C-a>", "<C-a>"},
+		{"<C-A>", "<C-a>"},
+		{"<C-S-a>", "<C-S-a>"},
+		{"<C-Space>", "<C-Space>"},
+		{"<S-Tab>", "<S-Tab>"},
+		{"<CR>", "<CR>"},
+		{"<Esc>", "<Esc>"},
+		{"<BS>", "<BS>"},
+		{"<Del>", "<Del>"},
+		{"<Insert>", "<Insert>"},
+		{"<Home>", "<Home>"},
+		{"<End>", "<End>"},
+		{"<PageUp>", "<PageUp>"},
+		{"<PageDown>", "<PageDown>"},
+		{"<S-Up>", "<S-Up>"},
+		{"<F1>", "<F1>"},
+		{"<F13>", "<F13>"},
+		{"<F24>", "<F24>"},
+		{"<S-F5>", "<S-F5>"},
+		{"<MouseMove>", "<MouseMove>"},
+		{"<LeftMouse>", "<LeftMouse>"},
+		{"<MiddleMouse>", "<MiddleMouse>"},
+		{"<RightMouse>", "<RightMouse>"},
+		{"<X1Mouse>", "<X1Mouse>"},
+		{"<X2Mouse>", "<X2Mouse>"},
+		{"<ScrollWheelUp>", "<ScrollWheelUp>"},
+		{"<ScrollWheelDown>", "<ScrollWheelDown>"},
+		{"<ScrollWheelLeft>", "<ScrollWheelLeft>"},
+		{"<ScrollWheelRight>", "<ScrollWheelRight>"},
+		{"<C-LeftMouse>", "<C-LeftMouse>"},
+		{"<Key-0>", "<Key-0>"},
+		{"<Key-272>", "<Key-272>"},
+		// Codes that have a name are written by that name
+		{"<Key-271>", "<Del>"},
+	}
+	for _, tt := range tests {
+		got, err := canonicalMappingKey(tt.key)
+		if err != nil {
+			t.Errorf("%q: %v", tt.key, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.key, got, tt.want)
+		}
+		again, err := canonicalMappingKey(got)
+		if err != nil {
+			t.Errorf("%q: %q does not parse: %v", tt.key, got, err)
+		} else if again != got {
+			t.Errorf("%q: %q is not stable, it becomes %q", tt.key, got, again)
+		}
+	}
+}
+
+func TestCanonicalMappingKeyErrors(t *testing.T) {
+	for _, key := range []string{
+		"<F0>",
+		"<F25>",
+		"<Q-x>",
+		"<C-a",
+		"<Nope>",
+		"<Key-x>",
+		"<Key-256>",
+		"<Key-99999>",
+		"ab",
+	} {
+		if got, err := canonicalMappingKey(key); err == nil {
+			t.Errorf("%q: got %q, want an error", key, got)
+		}
+	}
+}
+
+func TestResolveMappingsUnmatched(t *testing.T) {
+	r := &MappingRegistry{mappings: map[string]string{}}
+	if keys, err := r.resolveMappings("j<Esc"); err == nil {
+		t.Errorf("got %q, want an error for the unmatched <", keys)
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+)
+
+const (
+	mpvIPCTimeout = 2 * time.Second
+	// How long a launched mpv gets to open its socket
+	mpvStartTimeout = 10 * time.Second
+)
+
+// Client for a single mpv instance controlled over its JSON IPC socket
+type MpvIPC struct {
+	socketPath string
+	titles     map[string]string // URL -> stream name
+
+	mu        sync.Mutex
+	requestID int
+	starting  bool    // An mpv was launched and has not opened the socket yet
+	pending   [][]any // Commands given while starting, sent once it has
+}
+
+type mpvRequest struct {
+	Command   []any `json:"command"`
+	RequestID int   `json:"request_id"`
+}
+
+type mpvResponse struct {
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error"`
+	Event     string          `json:"event"`
+	RequestID int             `json:"request_id"`
+}
+
+type mpvPlaylistEntry struct {
+	Filename string `json:"filename"`
+	Title    string `json:"title"`
+	Current  bool   `json:"current"`
+}
+
+var ErrMpvNotRunning = errors.New("mpv is not running")
+
+func NewMpvIPC() *MpvIPC {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return &MpvIPC{
+		socketPath: filepath.Join(dir, fmt.Sprintf("streamshower-mpv-%d.sock", os.Getpid())),
+		titles:     make(map[string]string),
+	}
+}
+
+// Send {command} and wait for its response, skipping any events in between
+func (m *MpvIPC) send(command ...any) (json.RawMessage, error) {
+	conn, err := net.DialTimeout("unix", m.socketPath, mpvIPCTimeout)
+	if err != nil {
+		return nil, ErrMpvNotRunning
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(mpvIPCTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.requestID++
+	requestID := m.requestID
+	m.mu.Unlock()
+	req, err := json.Marshal(mpvRequest{Command: command, RequestID: requestID})
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Write(append(req, '\n'))
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var resp mpvResponse
+		if err = json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			return nil, err
+		}
+		if resp.Event != "" || resp.RequestID != requestID {
+			continue
+		}
+		if resp.Error != "success" {
+			return nil, fmt.Errorf("mpv: %s", resp.Error)
+		}
+		return resp.Data, nil
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("mpv closed the connection")
+}
+
+// Hold on to {command} if an mpv is still starting, reporting whether it was
+// held
+func (m *MpvIPC) holdWhileStarting(command ...any) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.starting {
+		m.pending = append(m.pending, command)
+	}
+	return m.starting
+}
+
+// Wait for the launched mpv to open its socket and send it the commands held
+// in the meantime
+func (m *MpvIPC) waitForSocket(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.Dial("unix", m.socketPath)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			m.mu.Lock()
+			m.starting = false
+			m.pending = nil
+			m.mu.Unlock()
+			return ErrMpvNotRunning
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for {
+		m.mu.Lock()
+		if len(m.pending) == 0 {
+			m.starting = false
+			m.mu.Unlock()
+			return nil
+		}
+		command := m.pending[0]
+		m.pending = m.pending[1:]
+		m.mu.Unlock()
+		if _, err := m.send(command...); err != nil {
+			m.mu.Lock()
+			m.starting = false
+			m.pending = nil
+			m.mu.Unlock()
+			return err
+		}
+	}
+}
+
+func (m *MpvIPC) getProperty(name string, v any) error {
+	data, err := m.send("get_property", name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Load {url} into the running mpv, or start a new mpv listening on the socket.
+// {mode} is either replace or append-play
+func (ui *UI) mpvLoad(data ls.StreamData, streamURL string, mode string) error {
+	mpv := ui.mpvIPC
+	mpv.titles[streamURL] = data.GetName()
+	if mpv.holdWhileStarting("loadfile", streamURL, mode) {
+		return nil
+	}
+	_, err := mpv.send("loadfile", streamURL, mode)
+	if !errors.Is(err, ErrMpvNotRunning) {
+		return err
+	}
+	return ui.startMpv(data, streamURL)
+}
+
+// Replace the playlist of mpv with {urls}, starting mpv if needed
+func (ui *UI) mpvLoadPlaylist(streams []ls.StreamData, urls []string) error {
+	mpv := ui.mpvIPC
+	for i, streamURL := range urls {
+		mpv.titles[streamURL] = streams[i].GetName()
+	}
+	if mpv.holdWhileStarting("loadfile", urls[0], "replace") {
+		for _, streamURL := range urls[1:] {
+			mpv.holdWhileStarting("loadfile", streamURL, "append")
+		}
+		return nil
+	}
+	_, err := mpv.send("loadfile", urls[0], "replace")
+	if errors.Is(err, ErrMpvNotRunning) {
+		return ui.startMpv(streams[0], urls...)
+	} else if err != nil {
+		return err
+	}
+	for _, streamURL := range urls[1:] {
+		_, err = mpv.send("loadfile", streamURL, "append")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start an mpv listening on the IPC socket that plays {urls} in order
+func (ui *UI) startMpv(data ls.StreamData, urls ...string) error {
+	mpv := ui.mpvIPC
+	// Remove a stale socket left behind by an mpv that has exited
+	_ = os.Remove(mpv.socketPath)
+	cmd := exec.Command(
+		"mpv",
+		append([]string{
+			"--idle=once",
+			"--force-window=immediate",
+			"--input-ipc-server=" + mpv.socketPath,
+		}, urls...)...,
+	)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Pgid:    0,
+		Setpgid: true,
+	}
+	err := ui.startJob(cmd, data, lnkOpenMpv)
+	if err != nil {
+		return err
+	}
+	// Until mpv opens the socket, further loads are held back instead of
+	// launching a second mpv
+	mpv.mu.Lock()
+	mpv.starting = true
+	mpv.mu.Unlock()
+	go func() {
+		err := mpv.waitForSocket(mpvStartTimeout)
+		if err != nil {
+			ui.app.QueueUpdateDraw(func() {
+				ui.mainPage.setStatus("StatusError", fmt.Sprintf("mpv: %s", err))
+			})
+		}
+	}()
+	return nil
+}
+
+func (ui *UI) mpvCommand(args []string) error {
+	mpv := ui.mpvIPC
+	var err error
+	switch args[0] {
+	case "add":
+		var data ls.StreamData
+		data, err = ui.getSelectedStreamData()
+		if err != nil {
+			return err
+		}
+		streamURL, err := ui.streamToURL(data, lnkOpenMpv)
+		if err != nil {
+			return err
+		}
+		return ui.mpvLoad(data, streamURL.String(), "append-play")
+	case "next":
+		_, err = mpv.send("playlist-next", "force")
+	case "pause":
+		_, err = mpv.send("cycle", "pause")
+	case "prev":
+		_, err = mpv.send("playlist-prev", "force")
+	case "quality":
+		if len(args) != 2 {
+			return errors.New("usage: :mpv quality {format}")
+		}
+		_, err = mpv.send("set_property", "ytdl-format", args[1])
+		if err != nil {
+			return err
+		}
+		// Reload the current entry for the format to take effect
+		var pos int
+		err = mpv.getProperty("playlist-pos", &pos)
+		if err != nil {
+			return err
+		}
+		_, err = mpv.send("playlist-play-index", pos)
+	case "status":
+		return ui.showMpvStatus()
+	case "stop":
+		_, err = mpv.send("quit")
+	case "volume":
+		if len(args) != 2 {
+			return errors.New("usage: :mpv volume {[+-]n}")
+		}
+		var n float64
+		n, err = strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid volume: %s", args[1])
+		}
+		if strings.HasPrefix(args[1], "+") || strings.HasPrefix(args[1], "-") {
+			_, err = mpv.send("add", "volume", n)
+		} else {
+			_, err = mpv.send("set_property", "volume", n)
+		}
+	default:
+		return fmt.Errorf("unknown mpv command: %s", args[0])
+	}
+	return err
+}
+
+func (ui *UI) showMpvStatus() error {
+	mpv := ui.mpvIPC
+	var playlist []mpvPlaylistEntry
+	err := mpv.getProperty("playlist", &playlist)
+	if err != nil {
+		return err
+	}
+	var (
+		paused bool
+		volume float64
+	)
+	_ = mpv.getProperty("pause", &paused)
+	_ = mpv.getProperty("volume", &volume)
+
+	state := "playing"
+	if paused {
+		state = "paused"
+	}
+	var status []byte
+	m := ui.mainPage
+	status = fmt.Appendf(status, "%s: %s\n", m.hlText("InfoLabel", "State"), state)
+	status = fmt.Appendf(status, "%s: %.0f\n", m.hlText("InfoLabel", "Volume"), volume)
+	status = fmt.Appendf(status, "%s:\n", m.hlText("InfoLabel", "Playlist"))
+	for i, entry := range playlist {
+		name := entry.Title
+		if title, ok := mpv.titles[entry.Filename]; ok {
+			name = title
+		}
+		if name == "" {
+			name = entry.Filename
+		}
+		marker := " "
+		if entry.Current {
+			marker = m.hlText("Playing", "▶")
+		}
+		status = fmt.Appendf(status, "%s %2d %s\n", marker, i+1, tview.Escape(name))
+	}
+	ui.mainPage.streamInfo.Clear()
+	ui.mainPage.streamInfo.ScrollTo(0, 0)
+	_, _ = ui.mainPage.streamInfo.Write(status)
+	ui.mainPage.streamInfo.SetTitle("MPV")
+	return nil
+}
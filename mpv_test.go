@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Stands in for mpv on an IPC socket, recording the commands it is sent
+type fakeMpv struct {
+	listener net.Listener
+	mu       sync.Mutex
+	commands [][]any
+	reply    func(command []any) (data any, errMsg string)
+}
+
+func newFakeMpv(t *testing.T, socketPath string) *fakeMpv {
+	t.Helper()
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeMpv{
+		listener: listener,
+		reply:    func([]any) (any, string) { return nil, "success" },
+	}
+	t.Cleanup(func() { listener.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeMpv) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeMpv) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req mpvRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, req.Command)
+		reply := f.reply
+		f.mu.Unlock()
+		data, errMsg := reply(req.Command)
+		// Events and replies to other clients come before the reply
+		lines := []any{
+			map[string]any{"event": "playback-restart"},
+			map[string]any{"request_id": req.RequestID + 100, "error": "success"},
+			map[string]any{"request_id": req.RequestID, "error": errMsg, "data": data},
+		}
+		for _, line := range lines {
+			b, _ := json.Marshal(line)
+			if _, err := conn.Write(append(b, '\n')); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeMpv) sent() [][]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]any(nil), f.commands...)
+}
+
+func newTestMpvIPC(t *testing.T) *MpvIPC {
+	return &MpvIPC{
+		socketPath: filepath.Join(t.TempDir(), "mpv.sock"),
+		titles:     make(map[string]string),
+	}
+}
+
+func TestMpvSend(t *testing.T) {
+	mpv := newTestMpvIPC(t)
+	fake := newFakeMpv(t, mpv.socketPath)
+	fake.reply = func(command []any) (any, string) {
+		if command[0] == "get_property" && command[1] == "volume" {
+			return 42.5, "success"
+		}
+		return nil, "property not found"
+	}
+
+	var volume float64
+	if err := mpv.getProperty("volume", &volume); err != nil {
+		t.Fatal(err)
+	}
+	if volume != 42.5 {
+		t.Errorf("volume = %v, want 42.5", volume)
+	}
+	_, err := mpv.send("get_property", "nonexistent")
+	if err == nil || err.Error() != "mpv: property not found" {
+		t.Errorf("error reply gave %v", err)
+	}
+
+	want := [][]any{
+		{"get_property", "volume"},
+		{"get_property", "nonexistent"},
+	}
+	if got := fake.sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %v, want %v", got, want)
+	}
+}
+
+func TestMpvSendNotRunning(t *testing.T) {
+	mpv := newTestMpvIPC(t)
+	if _, err := mpv.send("cycle", "pause"); !errors.Is(err, ErrMpvNotRunning) {
+		t.Errorf("send without a socket gave %v, want ErrMpvNotRunning", err)
+	}
+}
+
+func TestMpvHoldWhileStarting(t *testing.T) {
+	mpv := newTestMpvIPC(t)
+	if mpv.holdWhileStarting("loadfile", "a", "replace") {
+		t.Fatal("held a command without a starting mpv")
+	}
+
+	mpv.starting = true
+	if !mpv.holdWhileStarting("loadfile", "a", "replace") ||
+		!mpv.holdWhileStarting("loadfile", "b", "append-play") {
+		t.Fatal("did not hold the commands of a starting mpv")
+	}
+	done := make(chan error, 1)
+	go func() { done <- mpv.waitForSocket(5 * time.Second) }()
+	// The socket shows up after mpv has been waited on for a while
+	time.Sleep(100 * time.Millisecond)
+	fake := newFakeMpv(t, mpv.socketPath)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]any{
+		{"loadfile", "a", "replace"},
+		{"loadfile", "b", "append-play"},
+	}
+	if got := fake.sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %v, want %v", got, want)
+	}
+	if mpv.holdWhileStarting("cycle", "pause") {
+		t.Error("still holding commands once the socket is up")
+	}
+}
+
+func TestMpvWaitForSocketTimeout(t *testing.T) {
+	mpv := newTestMpvIPC(t)
+	mpv.starting = true
+	mpv.holdWhileStarting("loadfile", "a", "replace")
+	if err := mpv.waitForSocket(100 * time.Millisecond); !errors.Is(err, ErrMpvNotRunning) {
+		t.Errorf("waitForSocket gave %v, want ErrMpvNotRunning", err)
+	}
+	if mpv.starting || mpv.pending != nil {
+		t.Error("a failed start left commands held")
+	}
+}
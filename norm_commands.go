@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"strings"
+
+	"github.com/gdamore/tcell/v2"
 )
 
 type BuiltinHelp struct {
@@ -11,6 +13,7 @@ type BuiltinHelp struct {
 }
 
 var builtinHelps = []BuiltinHelp{
+	{Names: []string{"."}, Description: "Repeat the last command that performed an action (open, copyurl, focus, filters), a count prefix repeats it that many times"},
 	{Names: []string{"/", "?"}, Description: "Enter search mode"},
 	{Names: []string{":"}, Description: "Enter command mode"},
 	{Names: []string{"<Bar>"}, Description: "Special character representing `|` for chaining commands inside the rhs in mappings"},
@@ -18,17 +21,117 @@ var builtinHelps = []BuiltinHelp{
 	{Names: []string{"<C-e>"}, Description: "Scroll downwards one line"},
 	{Names: []string{"<C-n>", "<Down>", "j"}, Description: "Go down one line"},
 	{Names: []string{"<C-p>", "<Up>", "k"}, Description: "Go up one line"},
+	{Names: []string{"<Esc>"}, Description: "Clear the visual range and marks"},
+	{Names: []string{"<Tab>"}, Description: "Toggle the mark on the current stream, `:open` and `:copyurl` act on all marked streams"},
 	{Names: []string{"<C-u>"}, Description: "Scroll upwards half of the list"},
 	{Names: []string{"<C-y>"}, Description: "Scroll upwards one line"},
 	{Names: []string{"<C-z>"}, Description: "When used in mappings, this triggers autocomplete (like `wildcharm` in vim)"},
+	{Names: []string{"@{a-z}"}, Description: "Replay the keys recorded in register {a-z}, a count prefix replays it that many times"},
+	{Names: []string{"@@"}, Description: "Replay the previously replayed register"},
 	{Names: []string{"G"}, Description: "Go to last line of the list"},
 	{Names: []string{"M"}, Description: "Go to middle of the list"},
+	{Names: []string{"V"}, Description: "Start or end a visual range, `:open` and `:copyurl` act on every stream in it"},
 	{Names: []string{"N"}, Description: "Go to previous search match"},
 	{Names: []string{"g"}, Description: "Go to first line of the list"},
-	{Names: []string{"special-keys"}, Description: "<Bar> <Down> <CR> <Esc> <Left> <Right> <Space> <Tab> <Up> <C-a>..<C-z> <F1>..<F12>"},
+	{Names: []string{"c_<C-b>", "c_<C-e>"}, Description: "Move to the start or end of the command line"},
+	{Names: []string{"c_<C-r>"}, Description: "<C-r>{a-z} inserts a register, <C-r>: the last command line, <C-r>/ the last search and <C-r><C-w> the name of the stream under the cursor"},
+	{Names: []string{"c_<C-v>"}, Description: "Insert the next key literally, special keys as their notation like <Up>"},
+	{Names: []string{"c_<C-w>"}, Description: "Delete the word before the cursor"},
+	{Names: []string{"c_<Up>", "c_<Down>"}, Description: "Recall older or newer command lines that start with the text typed so far"},
+	{Names: []string{"special-keys"}, Description: "<Bar> <BS> <CR> <Del> <Down> <End> <Esc> <Home> <Insert> <Left> <lt> <PageDown> <PageUp> <Right> <Space> <Tab> <Up> <F1>..<F24> <LeftMouse> <MiddleMouse> <RightMouse> <ScrollWheelUp> <ScrollWheelDown>, with modifiers <C-..> <S-..> <A-..> (or <M-..>)"},
+	{Names: []string{"info-template"}, Description: "text/template over the stream, with the fields of the libstreams stream data ({{.Title}} {{.ViewerCount}} {{.Rustlers}} ...) and the functions: ago {time}, clock {time}, delta {stream} (change of viewers in the last 10 minutes), duration {duration}, since {time}, sparkline {stream} (viewers over the last fetches), escape {string} (for tview), hl {group} {text} (see `:highlight`)"},
+	{Names: []string{"launcher-options"}, Description: "-cwd={dir} runs the command in {dir};  -env={KEY=VALUE} adds to its environment. Templates get the `template-fields` plus .Method .WinOpen, where .URL is the url to open"},
+	{Names: []string{"list-columns"}, Description: "name channel viewers service title trend (both lists, trend sorts by growth);  game uptime language (twitch);  rustlers afk nsfw live (strims). `:set columns={col[:width]},...` picks the columns of `:set listmode=table`, `:set sortby=[-]{col}` sorts both lists, - descending"},
 	{Names: []string{"n"}, Description: "Go to next search match"},
-	{Names: []string{"option-list"}, Description: "strims: toggle strims window;  winopen: open links in new browser window"},
-	{Names: []string{"z"}, Description: "Redraw line at center of window"},
+	{Names: []string{"option-list"}, Description: "clipboard={auto|wl-copy|xclip|xsel|osc52|command}: how urls are copied;  columns={col[:width]},...: `list-columns` of the table mode;  fetchformat={format}: text next to the command line, see `statusline-items`;  groupby={game|language|service}: group the lists under foldable headers, empty disables;  listmode={list|table}: two lines or one row per stream;  newmark={duration}: mark streams that went live within it with +, 0 disables;  mpvipc: open mpv streams in a single mpv controlled by `:mpv`;  playing: mark streams with running jobs;  redrawinterval={duration}: shortest time between redraws of the countdown, uptimes and new marks, paused while the terminal is unfocused or idle, 0 disables;  sortby=[-]{col}: sort the lists by a `list-columns` column;  statusline={format}: title of the status window, see `statusline-items`;  strims: toggle strims window;  viewerhistory: keep the viewer counts behind sparklines across restarts;  winopen: open links in new browser window"},
+	{Names: []string{"q{a-z}"}, Description: "Record typed keys into register {a-z} ({A-Z} appends), q again stops recording"},
+	{Names: []string{"statusline-items"}, Description: "%s server  %t/%T twitch streams/shown  %r/%R strims streams/shown  %m last modified  %n seconds to next refresh  %f filter of the focused list  %o sortby  %j running jobs  %e last error  %% a literal %"},
+	{Names: []string{"template-fields"}, Description: "{{.Name}} {{.NameI}} (lowercase name) {{.Service}} {{.Title}} {{.Game}} {{.Viewers}} {{.URL}} (url reported by the service)"},
+	{Names: []string{"za"}, Description: "Toggle the fold of the group under the cursor, see `:set groupby`"},
+	{Names: []string{"zc"}, Description: "Fold the group under the cursor"},
+	{Names: []string{"zM"}, Description: "Fold every group"},
+	{Names: []string{"zo"}, Description: "Unfold the group under the cursor"},
+	{Names: []string{"zR"}, Description: "Unfold every group"},
+	{Names: []string{"zz", "z."}, Description: "Redraw line at center of window"},
+}
+
+// Add the :help entry of {bh} to {lines}
+func (m *MainPage) appendBuiltinHelp(lines []byte, bh BuiltinHelp) []byte {
+	names := make([]string, len(bh.Names))
+	for i, name := range bh.Names {
+		names[i] = m.hlText("InfoLabel", name)
+	}
+	return fmt.Appendf(lines, "([::b]builtin[::-]) %s\n  %s\n", strings.Join(names, " or "), bh.Description)
+}
+
+// Run {keys} as if typed in the focused list, resolving mappings unless
+// {noremap} is set. Command lines are executed directly once <CR> is reached
+// instead of going through the command line
+func (ui *UI) runNormalKeys(keys string, noremap bool) error {
+	registry := ui.mapRegistry
+	if noremap {
+		registry = &MappingRegistry{}
+	}
+	keyStrings, err := registry.resolveMappings(keys)
+	if err != nil {
+		return err
+	}
+	var cmdLine []rune
+	for _, key := range keyStrings {
+		if cmdLine != nil {
+			switch key {
+			case "<CR>":
+				err = ui.execCommandLineText(string(cmdLine))
+				if err != nil {
+					return err
+				}
+				cmdLine = nil
+			case "<Esc>":
+				cmdLine = nil
+			case "<BS>":
+				if len(cmdLine) > 1 {
+					cmdLine = cmdLine[:len(cmdLine)-1]
+				}
+			default:
+				ev, err := parseMappingKey(key)
+				if err != nil {
+					return err
+				}
+				if keyEv, ok := ev.(*tcell.EventKey); ok && keyEv.Key() == tcell.KeyRune {
+					cmdLine = append(cmdLine, keyEv.Rune())
+				}
+			}
+			continue
+		}
+		if ui.macroRegistry.pending != 0 {
+			ui.handleMacroRegister([]rune(key)[0])
+			continue
+		}
+		if ui.foldPending {
+			ui.foldPending = false
+			ui.execFoldKey(key)
+			continue
+		}
+		switch key {
+		case ":", "/", "?":
+			cmdLine = []rune(key)
+		default:
+			ui.execNormalKey(key)
+		}
+	}
+	return nil
+}
+
+// Execute a finished command line or search as if <CR> was pressed
+func (ui *UI) execCommandLineText(cmdLine string) error {
+	if strings.HasPrefix(cmdLine, ":") {
+		return ui.execCommandChainSilent(cmdLine)
+	}
+	err := ui.onTypeCommand(cmdLine)
+	if err != nil {
+		return err
+	}
+	return ui.execCommand(cmdLine)
 }
 
 func (ui *UI) moveUp() {
@@ -126,39 +229,31 @@ func (ui *UI) scrollInfo(amount int) {
 }
 
 func (ui *UI) searchNext() {
-	list := ui.mainPage.focusedList
-	count := list.GetItemCount()
-	if count == 0 || ui.mainPage.lastSearch == "" {
-		return
-	}
-	current := list.GetCurrentItem()
-	ui.mainPage.commandLine.SetText("/" + ui.mainPage.lastSearch)
-	for i := 1; i <= count; i++ {
-		index := (current + i) % count
-		primaryText, _ := list.GetItemText(index)
-		if strings.Contains(strings.ToLower(primaryText), strings.ToLower(ui.mainPage.lastSearch)) {
-			list.SetCurrentItem(index)
-			return
-		}
-	}
-	ui.mainPage.appStatusText.SetText(fmt.Sprintf("[yellow]No match for %q[-]", ui.mainPage.lastSearch))
+	ui.searchStep("/", 1)
 }
 
 func (ui *UI) searchPrev() {
+	ui.searchStep("?", -1)
+}
+
+// Go to the next stream in direction {step} whose name contains the last
+// search, looking at the streams behind the rows rather than rendering them
+func (ui *UI) searchStep(prefix string, step int) {
 	list := ui.mainPage.focusedList
 	count := list.GetItemCount()
 	if count == 0 || ui.mainPage.lastSearch == "" {
 		return
 	}
+	ui.mainPage.commandLine.SetText(prefix + ui.mainPage.lastSearch)
+	search := strings.ToLower(ui.mainPage.lastSearch)
 	current := list.GetCurrentItem()
-	ui.mainPage.commandLine.SetText("?" + ui.mainPage.lastSearch)
 	for i := 1; i <= count; i++ {
-		index := (current - i + count) % count
-		primaryText, _ := list.GetItemText(index)
-		if strings.Contains(strings.ToLower(primaryText), strings.ToLower(ui.mainPage.lastSearch)) {
+		index := ((current+step*i)%count + count) % count
+		data := ui.mainPage.streamAt(list, index)
+		if data != nil && strings.Contains(strings.ToLower(data.GetName()), search) {
 			list.SetCurrentItem(index)
 			return
 		}
 	}
-	ui.mainPage.appStatusText.SetText(fmt.Sprintf("[yellow]No match for %q[-]", ui.mainPage.lastSearch))
+	ui.mainPage.setStatus("StatusInfo", fmt.Sprintf("No match for %q", ui.mainPage.lastSearch))
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var boolOptions = []string{"mpvipc", "playing", "strims", "viewerhistory", "winopen"}
+
+var valueOptions = []string{"clipboard", "columns", "fetchformat", "groupby", "listmode", "newmark", "redrawinterval", "sortby", "statusline"}
+
+// Rejoin arguments that were split on a backslash-escaped space
+func joinEscapedSpaces(args []string) []string {
+	var joined []string
+	var cur string
+	for _, arg := range args {
+		if before, ok := strings.CutSuffix(cur, "\\"); ok {
+			cur = before + " " + arg
+		} else {
+			if cur != "" {
+				joined = append(joined, cur)
+			}
+			cur = arg
+		}
+	}
+	if cur != "" {
+		joined = append(joined, cur)
+	}
+	return joined
+}
+
+func (ui *UI) setOption(arg string, bang bool) error {
+	if name, value, ok := strings.Cut(arg, "="); ok {
+		return ui.setValueOption(name, value)
+	}
+	if name, ok := strings.CutSuffix(arg, "?"); ok {
+		return ui.showOption(name)
+	}
+	for _, opt := range valueOptions {
+		if arg == opt {
+			return ui.showOption(arg)
+		}
+	}
+	return ui.setBoolOption(arg, bang)
+}
+
+func (ui *UI) showOption(name string) error {
+	var value string
+	switch name {
+	case "clipboard":
+		value = ui.mainPage.clipboard
+	case "columns":
+		value = formatColumns(ui.mainPage.columns)
+	case "fetchformat":
+		value = ui.mainPage.fetchFormat
+	case "groupby":
+		value = ui.mainPage.groupBy
+	case "listmode":
+		value = ui.mainPage.listMode
+	case "mpvipc":
+		value = fmt.Sprint(ui.mainPage.mpvipc)
+	case "newmark":
+		value = ui.mainPage.newMark.String()
+	case "playing":
+		value = fmt.Sprint(ui.mainPage.playing)
+	case "redrawinterval":
+		value = ui.mainPage.redrawInterval.String()
+	case "sortby":
+		value = ui.mainPage.sortBy
+		if ui.mainPage.sortDesc {
+			value = "-" + value
+		}
+	case "statusline":
+		value = ui.mainPage.statusline
+	case "strims":
+		value = fmt.Sprint(ui.mainPage.strims)
+	case "viewerhistory":
+		value = fmt.Sprint(ui.mainPage.viewerhistory)
+	case "winopen":
+		value = fmt.Sprint(ui.mainPage.winopen)
+	default:
+		return fmt.Errorf("unknown option %s", name)
+	}
+	ui.mainPage.appStatusText.SetText(fmt.Sprintf("%s=%s", name, value))
+	return nil
+}
+
+func (ui *UI) setValueOption(name string, value string) error {
+	switch name {
+	case "clipboard":
+		if value == "" {
+			value = "auto"
+		}
+		ui.mainPage.clipboard = value
+	case "columns":
+		if value == "" {
+			value = defaultColumns
+		}
+		columns, err := parseColumns(value)
+		if err != nil {
+			return err
+		}
+		ui.mainPage.columns = columns
+		ui.mainPage.applyListMode()
+	case "fetchformat":
+		if value == "" {
+			value = defaultFetchFormat
+		}
+		ui.mainPage.fetchFormat = value
+	case "groupby":
+		if value != "" && !slices.Contains(groupByColumns, value) {
+			return fmt.Errorf("invalid groupby %s", value)
+		}
+		ui.mainPage.groupBy = value
+		ui.mainPage.refreshTwitchList()
+		ui.mainPage.refreshStrimsList()
+	case "listmode":
+		if value != "list" && value != "table" {
+			return fmt.Errorf("invalid listmode %s", value)
+		}
+		ui.mainPage.listMode = value
+		ui.mainPage.applyListMode()
+	case "newmark":
+		if value == "" {
+			value = defaultNewMark.String()
+		}
+		newMark, err := time.ParseDuration(value)
+		if err != nil || newMark < 0 {
+			return fmt.Errorf("invalid newmark %s", value)
+		}
+		ui.mainPage.newMark = newMark
+		ui.mainPage.refreshTwitchList()
+		ui.mainPage.refreshStrimsList()
+		ui.redraw.wakeUp()
+	case "redrawinterval":
+		if value == "" {
+			value = defaultRedrawInterval.String()
+		}
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 {
+			return fmt.Errorf("invalid redrawinterval %s", value)
+		}
+		ui.mainPage.redrawInterval = interval
+		ui.redraw.wakeUp()
+	case "sortby":
+		sortBy, desc, err := parseSortBy(value)
+		if err != nil {
+			return err
+		}
+		ui.mainPage.sortBy, ui.mainPage.sortDesc = sortBy, desc
+		ui.mainPage.refreshTwitchList()
+		ui.mainPage.refreshStrimsList()
+	case "statusline":
+		if value == "" {
+			value = defaultStatusline
+		}
+		ui.mainPage.statusline = value
+	default:
+		return fmt.Errorf("unknown option %s", name)
+	}
+	return nil
+}
+
+func (ui *UI) setBoolOption(arg string, bang bool) error {
+	var prefixno bool
+	if strings.HasPrefix(arg, "no") {
+		prefixno = true
+		arg = strings.TrimPrefix(arg, "no")
+	}
+	switch arg {
+	case "mpvipc":
+		if bang {
+			ui.mainPage.mpvipc = !ui.mainPage.mpvipc
+		} else if prefixno {
+			ui.mainPage.mpvipc = false
+		} else {
+			ui.mainPage.mpvipc = true
+		}
+	case "playing":
+		if bang {
+			ui.mainPage.playing = !ui.mainPage.playing
+		} else if prefixno {
+			ui.mainPage.playing = false
+		} else {
+			ui.mainPage.playing = true
+		}
+		ui.mainPage.refreshTwitchList()
+		ui.mainPage.refreshStrimsList()
+	case "strims":
+		if bang {
+			ui.toggleStrimsList()
+		} else if prefixno {
+			ui.disableStrimsList()
+		} else {
+			ui.enableStrimsList()
+		}
+		ui.mainPage.refreshTwitchList()
+		ui.mainPage.refreshStrimsList()
+	case "viewerhistory":
+		if bang {
+			ui.mainPage.viewerhistory = !ui.mainPage.viewerhistory
+		} else if prefixno {
+			ui.mainPage.viewerhistory = false
+		} else {
+			ui.mainPage.viewerhistory = true
+		}
+	case "winopen":
+		if bang {
+			ui.mainPage.winopen = !ui.mainPage.winopen
+		} else if prefixno {
+			ui.mainPage.winopen = false
+		} else {
+			ui.mainPage.winopen = true
+		}
+	default:
+		return fmt.Errorf("unknown option %s", arg)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"slices"
@@ -15,7 +16,7 @@ func (ui *UI) onTypeCommandChain(cmdLine string) {
 		ui.app.SetFocus(ui.mainPage.focusedList)
 		return
 	}
-	commands := strings.Split(cmdLine, "|")
+	commands := ui.cmdRegistry.splitCommandChain(cmdLine)
 	for i, cmd := range commands {
 		cmd = strings.TrimSpace(cmd)
 		if cmd == "" {
@@ -27,7 +28,7 @@ func (ui *UI) onTypeCommandChain(cmdLine string) {
 		}
 		err := ui.onTypeCommand(cmd)
 		if err != nil {
-			ui.mainPage.appStatusText.SetText(err.Error())
+			ui.mainPage.setStatus("StatusError", err.Error())
 		}
 	}
 }
@@ -39,7 +40,7 @@ func (ui *UI) onTypeCommand(cmd string) error {
 		if namepart == "" {
 			return nil
 		}
-		possible := ui.cmdRegistry.matchPossibleCommands(namepart)
+		possible := ui.cmdRegistry.findCommand(namepart)
 		switch len(possible) {
 		case 1:
 			if len(args) < possible[0].MinArgs {
@@ -74,7 +75,7 @@ func (ui *UI) execCommandChainCallback(key tcell.Key) {
 		ui.cmdRegistry.histIndex = len(ui.cmdRegistry.history)
 		err := ui.execCommandChainSilent(cmdLine)
 		if err != nil {
-			ui.mainPage.appStatusText.SetText(err.Error())
+			ui.mainPage.setStatus("StatusError", err.Error())
 		}
 		fallthrough
 	case tcell.KeyEsc:
@@ -82,13 +83,24 @@ func (ui *UI) execCommandChainCallback(key tcell.Key) {
 	}
 }
 
-// Execute the command chain from a mapping
-func (ui *UI) execCommandChainMapping(keyStrings []string) error {
+// Execute the command chain from a mapping. Mouse keys in it happen where
+// {origin} did when the mouse triggered the mapping, otherwise on the current
+// row of the focused list
+func (ui *UI) execCommandChainMapping(keyStrings []string, origin *tcell.EventMouse) error {
 	for _, mapping := range keyStrings {
 		k, err := parseMappingKey(mapping)
 		if err != nil {
 			return err
 		}
+		if mouseEv, ok := k.(*tcell.EventMouse); ok {
+			var x, y int
+			if origin != nil {
+				x, y = origin.Position()
+			} else {
+				x, y = ui.mainPage.focusedList.currentRowPosition()
+			}
+			k = tcell.NewEventMouse(x, y, mouseEv.Buttons(), mouseEv.Modifiers())
+		}
 		ui.app.QueueEvent(k)
 	}
 	return nil
@@ -97,7 +109,15 @@ func (ui *UI) execCommandChainMapping(keyStrings []string) error {
 // Execute a complete chain (without trailing special characters) as is,
 // without printing
 func (ui *UI) execCommandChainSilent(cmdLine string) error {
-	commands := strings.Split(cmdLine, "|")
+	outerRepeatable := ui.cmdRegistry.repeatable
+	ui.cmdRegistry.repeatable = false
+	defer func() {
+		if ui.cmdRegistry.repeatable {
+			ui.cmdRegistry.lastChange = cmdLine
+		}
+		ui.cmdRegistry.repeatable = ui.cmdRegistry.repeatable || outerRepeatable
+	}()
+	commands := ui.cmdRegistry.splitCommandChain(cmdLine)
 	for i, cmd := range commands {
 		cmd = strings.TrimSpace(cmd)
 		if cmd == "" {
@@ -115,6 +135,36 @@ func (ui *UI) execCommandChainSilent(cmdLine string) error {
 	return nil
 }
 
+// Split {cmdLine} into the commands separated by |. A command that takes | as
+// part of its arguments gets the rest of the line, like :normal in vim
+func (r *CommandRegistry) splitCommandChain(cmdLine string) []string {
+	var commands []string
+	for i := 0; ; i++ {
+		cmd, rest, found := strings.Cut(cmdLine, "|")
+		if !found || r.takesBar(cmd, i > 0) {
+			return append(commands, cmdLine)
+		}
+		commands = append(commands, cmd)
+		cmdLine = rest
+	}
+}
+
+// Whether {cmd} is a command that sees | as part of its arguments, {implied}
+// when it follows a | and needs no :
+func (r *CommandRegistry) takesBar(cmd string, implied bool) bool {
+	cmd = strings.TrimSpace(cmd)
+	after, ok := strings.CutPrefix(cmd, ":")
+	if !ok && !implied {
+		return false
+	}
+	namepart, _, _ := parseCommandParts(after)
+	if namepart == "" {
+		return false
+	}
+	possible := r.findCommand(namepart)
+	return len(possible) == 1 && possible[0].TakesBar
+}
+
 // Execute a command
 func (ui *UI) execCommand(cmdLine string) error {
 	cmdLine = strings.TrimSpace(cmdLine)
@@ -127,10 +177,10 @@ func (ui *UI) execCommand(cmdLine string) error {
 		if namepart == "" {
 			return nil
 		}
-		possible := ui.cmdRegistry.matchPossibleCommands(namepart)
+		possible := ui.cmdRegistry.findCommand(namepart)
 		switch len(possible) {
 		case 0:
-			return fmt.Errorf("[red]Unknown command: %s[-]", namepart)
+			return fmt.Errorf("Unknown command: %s", namepart)
 		case 1:
 			if len(args) < possible[0].MinArgs {
 				return fmt.Errorf("argument required for command: %s", possible[0].Name)
@@ -143,12 +193,15 @@ func (ui *UI) execCommand(cmdLine string) error {
 					return err
 				}
 			}
+			if possible[0].Repeatable {
+				ui.cmdRegistry.repeatable = true
+			}
 		default:
 			var names []string
 			for _, m := range possible {
 				names = append(names, m.Name)
 			}
-			return fmt.Errorf("[red]Ambiguous: %s (could be %s)[-]", namepart, strings.Join(names, ", "))
+			return fmt.Errorf("Ambiguous: %s (could be %s)", namepart, strings.Join(names, ", "))
 		}
 	} else if strings.HasPrefix(cmdLine, "/") {
 		ui.searchNext()
@@ -158,6 +211,21 @@ func (ui *UI) execCommand(cmdLine string) error {
 	return nil
 }
 
+// Repeat the last command chain that performed an action {count} times
+func (ui *UI) repeatLastChange(count int) error {
+	lastChange := ui.cmdRegistry.lastChange
+	if lastChange == "" {
+		return errors.New("no previous action to repeat")
+	}
+	for range max(count, 1) {
+		err := ui.execCommandChainSilent(lastChange)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func extractCmdName(text string) (name string, rest string) {
 	for i, r := range text {
 		if !unicode.IsLetter(r) {
@@ -167,9 +235,10 @@ func extractCmdName(text string) (name string, rest string) {
 	return text, ""
 }
 
+var filterArgRegexp = regexp.MustCompile(`^\/([^\/]*)\/([dp])$`)
+
 func (m *MainPage) applyFilterFromArg(arg string, bang bool, invertMatching bool) {
-	re := regexp.MustCompile(`^\/([^\/]*)\/([dp])$`)
-	matches := re.FindStringSubmatch(arg)
+	matches := filterArgRegexp.FindStringSubmatch(arg)
 	if len(matches) <= 2 {
 		return
 	}
@@ -194,6 +263,34 @@ func (m *MainPage) applyFilterFromArg(arg string, bang bool, invertMatching bool
 	m.refreshStrimsList()
 }
 
+// Commands that {name} can refer to, preferring an exact match over
+// abbreviations
+func (r *CommandRegistry) findCommand(name string) []*ExCommand {
+	for _, cmd := range r.commands {
+		if cmd.Name == name {
+			return []*ExCommand{cmd}
+		}
+	}
+	possible := r.matchPossibleCommands(name)
+	if len(possible) > 1 {
+		// Prefer the command with the longest documented abbreviation, the
+		// part of its usage before the first optional bracket, that is typed
+		// out, so :c is copyurl while :com is command
+		var best *ExCommand
+		bestLen := 0
+		for _, cmd := range possible {
+			abbrev, _, found := strings.Cut(cmd.Usage, "[")
+			if found && len(abbrev) > bestLen && strings.HasPrefix(name, abbrev) {
+				best, bestLen = cmd, len(abbrev)
+			}
+		}
+		if best != nil {
+			return []*ExCommand{best}
+		}
+	}
+	return possible
+}
+
 func (r *CommandRegistry) matchPossibleCommands(name string) []*ExCommand {
 	var possible []*ExCommand
 	for _, cmd := range r.commands {
@@ -245,7 +342,9 @@ func parseCommandParts(input string) (string, []string, bool) {
 	return name, args, bang
 }
 
-func highlightSearch(text string, search string) string {
+// Highlight the first match of the last search in {text}
+func (m *MainPage) highlightSearch(group, text string) string {
+	search := m.lastSearch
 	if search == "" {
 		return text
 	}
@@ -255,7 +354,7 @@ func highlightSearch(text string, search string) string {
 	if idx == -1 {
 		return text
 	}
-	return text[:idx] + "[red]" + text[idx:idx+len(search)] + "[-]" + text[idx+len(search):]
+	return text[:idx] + m.hlTextIn(group, "Search", text[idx:idx+len(search)]) + text[idx+len(search):]
 }
 
 // Variation selectors seem to cause issues with tview rendering, remove them
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+)
+
+const queueFile = "queue.json"
+
+// A stream saved for later, with the mpv URL resolved when it was queued so
+// that it can be played after the channel goes offline
+type QueueEntry struct {
+	Added   time.Time `json:"added"`
+	Service string    `json:"service"`
+	Name    string    `json:"name"`
+	Title   string    `json:"title"`
+	URL     string    `json:"url"`
+}
+
+type WatchQueue struct {
+	entries []QueueEntry
+}
+
+func NewWatchQueue() *WatchQueue {
+	return &WatchQueue{}
+}
+
+func (q *WatchQueue) load() error {
+	path, err := statePath(queueFile)
+	if err != nil {
+		return err
+	}
+	queueBytes, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(queueBytes, &q.entries)
+}
+
+// Write the queue to disk right away so that it survives crashes
+func (q *WatchQueue) save() error {
+	path, err := statePath(queueFile)
+	if err != nil {
+		return err
+	}
+	queueBytes, err := json.Marshal(q.entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, queueBytes, 0o600)
+}
+
+func (q *WatchQueue) contains(url string) bool {
+	for _, entry := range q.entries {
+		if entry.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+func (ui *UI) queueSelectedStreams() error {
+	streams, err := ui.getSelectedStreams()
+	if err != nil {
+		return err
+	}
+	added := 0
+	for _, data := range streams {
+		streamURL, err := ui.streamToURL(data, lnkOpenMpv)
+		if err != nil {
+			return err
+		}
+		if ui.watchQueue.contains(streamURL.String()) {
+			continue
+		}
+		ui.watchQueue.entries = append(ui.watchQueue.entries, QueueEntry{
+			Added:   time.Now(),
+			Service: data.GetService(),
+			Name:    data.GetName(),
+			Title:   streamTemplateFields(data)["Title"].(string),
+			URL:     streamURL.String(),
+		})
+		added++
+	}
+	ui.clearSelection()
+	ui.mainPage.setStatus("StatusOk", fmt.Sprintf("Queued %d stream(s), %d in queue", added, len(ui.watchQueue.entries)))
+	return ui.watchQueue.save()
+}
+
+func (ui *UI) describeQueue() []byte {
+	var lines []byte
+	for i, entry := range ui.watchQueue.entries {
+		live := ui.mainPage.hlText("InfoDim", "offline")
+		if _, ok := ui.mainPage.findLiveStream(entry.Service, entry.Name); ok {
+			live = ui.mainPage.hlText("StatusOk", "live   ")
+		}
+		lines = fmt.Appendf(
+			lines,
+			"%s %s %s/%s\n    %s\n",
+			ui.mainPage.hlText("InfoLabel", fmt.Sprintf("%-3d", i+1)),
+			live,
+			entry.Service,
+			tview.Escape(entry.Name),
+			tview.Escape(entry.URL),
+		)
+		if entry.Title != "" {
+			lines = fmt.Appendf(lines, "    %s\n", tview.Escape(entry.Title))
+		}
+	}
+	return lines
+}
+
+// Play every queued URL in order in a single mpv
+func (ui *UI) openQueue() error {
+	if len(ui.watchQueue.entries) == 0 {
+		return errors.New("queue is empty")
+	}
+	var streams []ls.StreamData
+	var urls []string
+	for _, entry := range ui.watchQueue.entries {
+		streams = append(streams, ui.mainPage.streamOrOffline(entry.Service, entry.Name, entry.Title, ""))
+		urls = append(urls, entry.URL)
+	}
+	return ui.mpvLoadPlaylist(streams, urls)
+}
+
+func (ui *UI) queueCommand(args []string) error {
+	if len(args) == 0 {
+		if len(ui.watchQueue.entries) == 0 {
+			return errors.New("queue is empty")
+		}
+		ui.mainPage.streamInfo.Clear()
+		ui.mainPage.streamInfo.ScrollTo(0, 0)
+		_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+		_, _ = ui.mainPage.streamInfo.Write(ui.describeQueue())
+		ui.mainPage.streamInfo.SetTitle("QUEUE")
+		return nil
+	}
+	switch args[0] {
+	case "add":
+		return ui.queueSelectedStreams()
+	case "clear":
+		ui.watchQueue.entries = nil
+		return ui.watchQueue.save()
+	case "open":
+		return ui.openQueue()
+	default:
+		return fmt.Errorf("unknown queue command %s", args[0])
+	}
+}
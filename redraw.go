@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	defaultRedrawInterval = time.Second
+	// Timed redraws stop after this long without keys or mouse events
+	idleTimeout = 10 * time.Minute
+)
+
+// Decides when the parts of the screen that change with time, the refresh
+// countdown, uptimes and new marks, have to be redrawn
+type RedrawScheduler struct {
+	mu        sync.Mutex
+	unfocused bool // The terminal reported losing focus
+	lastInput time.Time
+	infoDue   time.Time // When the uptime in the info pane changes, UI goroutine only
+	wake      chan struct{}
+}
+
+func NewRedrawScheduler() *RedrawScheduler {
+	return &RedrawScheduler{
+		lastInput: time.Now(),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Reschedule the timed redraws right away
+func (r *RedrawScheduler) wakeUp() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RedrawScheduler) paused(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unfocused || now.Sub(r.lastInput) > idleTimeout
+}
+
+func (r *RedrawScheduler) setFocused(focused bool) {
+	r.mu.Lock()
+	r.unfocused = !focused
+	r.mu.Unlock()
+	if focused {
+		r.wakeUp()
+	}
+}
+
+// Note a key or mouse event, resuming timed redraws when idle
+func (r *RedrawScheduler) touch() {
+	r.mu.Lock()
+	idle := time.Since(r.lastInput) > idleTimeout
+	r.lastInput = time.Now()
+	r.mu.Unlock()
+	if idle {
+		r.wakeUp()
+	}
+}
+
+// A screen that passes terminal focus changes to {onFocus}, tview drops them
+type focusScreen struct {
+	tcell.Screen
+	onFocus func(focused bool)
+}
+
+func (s *focusScreen) PollEvent() tcell.Event {
+	for {
+		ev := s.Screen.PollEvent()
+		if focus, ok := ev.(*tcell.EventFocus); ok {
+			s.onFocus(focus.Focused)
+			continue
+		}
+		return ev
+	}
+}
+
+func (ui *UI) redrawLoop(ctx context.Context) {
+	defer ui.wg.Done()
+
+	timer := time.NewTimer(ui.mainPage.redrawInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ui.redraw.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+		next := make(chan time.Duration, 1)
+		ui.app.QueueUpdate(func() {
+			next <- ui.redrawTick(time.Now())
+		})
+		select {
+		case <-ctx.Done():
+			return
+		case delay := <-next:
+			if delay > 0 {
+				timer.Reset(delay)
+			}
+		}
+	}
+}
+
+// Redraw what is due and return how long until the next timed redraw, 0 when
+// there is none
+func (ui *UI) redrawTick(now time.Time) time.Duration {
+	m := ui.mainPage
+	if m.redrawInterval <= 0 || ui.redraw.paused(now) {
+		return 0
+	}
+	if due := ui.redraw.infoDue; !due.IsZero() && !now.Before(due) {
+		row, col := m.streamInfo.GetScrollOffset()
+		m.redrawStreamInfo()
+		m.streamInfo.ScrollTo(row, col)
+	}
+	// tview only draws the whole screen, tcell then sends the terminal the
+	// cells that changed
+	ui.app.ForceDraw()
+
+	status, info, list := ui.untilChange(now)
+	ui.redraw.infoDue = time.Time{}
+	if info > 0 {
+		ui.redraw.infoDue = now.Add(info)
+	}
+	var delay time.Duration
+	for _, d := range []time.Duration{status, info, list} {
+		if d > 0 && (delay == 0 || d < delay) {
+			delay = d
+		}
+	}
+	if delay == 0 {
+		return 0
+	}
+	return max(delay, m.redrawInterval)
+}
+
+// How long until the refresh countdown, the uptime in the info pane and the
+// rows of the lists, through the uptime column or a new mark, next change, 0
+// for those that are not shown
+func (ui *UI) untilChange(now time.Time) (status, info, list time.Duration) {
+	m := ui.mainPage
+	if ui.fetchMeta != nil && strings.Contains(m.statusline+m.fetchFormat, "%n") {
+		// %n shows the seconds rounded, it changes on every half second
+		next := ui.fetchMeta.LastModified.Add(ui.fetchMeta.RefreshInterval)
+		status = (next.Sub(now) - time.Second/2) % time.Second
+		if status <= 0 {
+			status += time.Second
+		}
+	}
+	if m.focusedList == m.twitchList {
+		if data := m.streamAt(m.twitchList, m.twitchList.GetCurrentItem()); data != nil {
+			info = untilMinute(now.Sub(data.(*ls.TwitchStreamData).StartedAt))
+		}
+	}
+	if m.listMode == "table" && slices.ContainsFunc(m.columns, func(c ListColumn) bool { return c.name == "uptime" }) {
+		for _, ix := range m.twitchFilter.indexMapping {
+			if ix < 0 || ix >= len(m.streams.Twitch.Data) {
+				continue
+			}
+			d := untilMinute(now.Sub(m.streams.Twitch.Data[ix].StartedAt))
+			if list == 0 || d < list {
+				list = d
+			}
+		}
+	}
+	if d := m.changeLog.untilMarkExpires(now, m.newMark); d > 0 && (list == 0 || d < list) {
+		list = d
+	}
+	return status, info, list
+}
+
+// Time until an age of {age} reaches the next whole minute
+func untilMinute(age time.Duration) time.Duration {
+	return time.Minute - age%time.Minute
+}
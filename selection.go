@@ -0,0 +1,233 @@
+package main
+
+import (
+	ls "github.com/HoppenR/libstreams"
+)
+
+// Streams selected in a list, tracked by stream identity so that the
+// selection survives refreshes and filtering
+type Selection struct {
+	marked map[string]bool
+	anchor string // Start of the visual range, "" when not in visual mode
+}
+
+func NewSelection() *Selection {
+	return &Selection{marked: make(map[string]bool)}
+}
+
+// Identity of a stream that is stable across fetches
+func streamKey(data ls.StreamData) string {
+	return data.GetService() + "/" + data.GetName()
+}
+
+func (s *Selection) active() bool {
+	return s.anchor != "" || len(s.marked) > 0
+}
+
+func (s *Selection) clear() {
+	s.anchor = ""
+	clear(s.marked)
+}
+
+func (m *MainPage) selectionFor(list *StreamList) *Selection {
+	if list == m.strimsList {
+		return m.strimsSelection
+	}
+	return m.twitchSelection
+}
+
+func (m *MainPage) filterFor(list *StreamList) *FilterInput {
+	if list == m.strimsList {
+		return m.strimsFilter
+	}
+	return m.twitchFilter
+}
+
+// Stream shown at index {listIdx} of {list}, or nil if there is none
+func (m *MainPage) streamAt(list *StreamList, listIdx int) ls.StreamData {
+	mapping := m.filterFor(list).indexMapping
+	if listIdx < 0 || listIdx >= len(mapping) || mapping[listIdx] < 0 {
+		// Out of range or a group header
+		return nil
+	}
+	ix := mapping[listIdx]
+	if list == m.strimsList {
+		if ix >= len(m.streams.Strims.Data) {
+			return nil
+		}
+		return &m.streams.Strims.Data[ix]
+	}
+	if ix >= len(m.streams.Twitch.Data) {
+		return nil
+	}
+	return &m.streams.Twitch.Data[ix]
+}
+
+// Keys of the marked streams and those in the visual range of {list}
+func (m *MainPage) selectedKeys(list *StreamList) map[string]bool {
+	sel := m.selectionFor(list)
+	keys := make(map[string]bool, len(sel.marked))
+	for key := range sel.marked {
+		keys[key] = true
+	}
+	if sel.anchor == "" {
+		return keys
+	}
+	count := len(m.filterFor(list).indexMapping)
+	cur := list.GetCurrentItem()
+	anchorIdx := cur
+	for i := range count {
+		if data := m.streamAt(list, i); data != nil && streamKey(data) == sel.anchor {
+			anchorIdx = i
+			break
+		}
+	}
+	for i := min(anchorIdx, cur); i <= max(anchorIdx, cur) && i < count; i++ {
+		if data := m.streamAt(list, i); data != nil {
+			keys[streamKey(data)] = true
+		}
+	}
+	return keys
+}
+
+// The selected streams of the focused list in display order, or the stream
+// under the cursor if nothing is selected
+func (ui *UI) getSelectedStreams() ([]ls.StreamData, error) {
+	list := ui.mainPage.focusedList
+	if !ui.mainPage.selectionFor(list).active() {
+		data, err := ui.getSelectedStreamData()
+		if err != nil {
+			return nil, err
+		}
+		return []ls.StreamData{data}, nil
+	}
+	keys := ui.mainPage.selectedKeys(list)
+	var streams []ls.StreamData
+	for i := range len(ui.mainPage.filterFor(list).indexMapping) {
+		data := ui.mainPage.streamAt(list, i)
+		if data != nil && keys[streamKey(data)] {
+			streams = append(streams, data)
+		}
+	}
+	return streams, nil
+}
+
+func (ui *UI) toggleVisual() {
+	sel := ui.mainPage.selectionFor(ui.mainPage.focusedList)
+	if sel.anchor != "" {
+		// Keep the range as marks when leaving visual mode
+		for key := range ui.mainPage.selectedKeys(ui.mainPage.focusedList) {
+			sel.marked[key] = true
+		}
+		sel.anchor = ""
+	} else if data, err := ui.getSelectedStreamData(); err == nil {
+		sel.anchor = streamKey(data)
+	}
+	ui.refreshFocusedList()
+}
+
+func (ui *UI) toggleMark() {
+	data, err := ui.getSelectedStreamData()
+	if err != nil {
+		return
+	}
+	sel := ui.mainPage.selectionFor(ui.mainPage.focusedList)
+	key := streamKey(data)
+	if sel.marked[key] {
+		delete(sel.marked, key)
+	} else {
+		sel.marked[key] = true
+	}
+	ui.moveDown()
+	ui.refreshFocusedList()
+}
+
+func (ui *UI) clearSelection() {
+	sel := ui.mainPage.selectionFor(ui.mainPage.focusedList)
+	if sel.active() {
+		sel.clear()
+		ui.refreshFocusedList()
+	}
+}
+
+func (ui *UI) refreshFocusedList() {
+	switch ui.mainPage.focusedList {
+	case ui.mainPage.twitchList:
+		ui.mainPage.refreshTwitchList()
+	case ui.mainPage.strimsList:
+		ui.mainPage.refreshStrimsList()
+	}
+}
+
+// Identity of row {listIdx} of {list}, the stream key or the group of a
+// group header
+func (m *MainPage) rowKey(list *StreamList, listIdx int) string {
+	if data := m.streamAt(list, listIdx); data != nil {
+		return streamKey(data)
+	}
+	if groups := m.filterFor(list).rowGroups; listIdx < len(groups) {
+		return "group:" + groups[listIdx]
+	}
+	return ""
+}
+
+// Position of the cursor of a list and the rows around it, saved before a
+// refresh so it can be put back on the same stream
+type listCursor struct {
+	idx    int
+	offset int
+	keys   []string
+}
+
+// Must be called before the rows or the streams behind them change
+func (m *MainPage) saveCursor(list *StreamList) listCursor {
+	cursor := listCursor{
+		idx:  list.GetCurrentItem(),
+		keys: make([]string, list.GetItemCount()),
+	}
+	cursor.offset, _ = list.GetOffset()
+	for i := range cursor.keys {
+		cursor.keys[i] = m.rowKey(list, i)
+	}
+	return cursor
+}
+
+// Swap in a new snapshot of the streams, keeping the cursors of both lists on
+// the same streams
+func (m *MainPage) setStreams(streams *ls.Streams) {
+	twitch := m.saveCursor(m.twitchList)
+	strims := m.saveCursor(m.strimsList)
+	m.streams = streams
+	m.restoreTwitchList(twitch)
+	m.restoreStrimsList(strims)
+}
+
+// Put the cursor back on the stream it was on when {cursor} was saved. When
+// the stream is gone the cursor moves to its nearest neighbor that is still
+// shown
+func (m *MainPage) restoreCursor(list *StreamList, cursor listCursor) {
+	oldIdx, oldOffset, oldKeys := cursor.idx, cursor.offset, cursor.keys
+	newRows := make(map[string]int, list.GetItemCount())
+	for i := range list.GetItemCount() {
+		if key := m.rowKey(list, i); key != "" {
+			newRows[key] = i
+		}
+	}
+	newIdx := min(oldIdx, list.GetItemCount()-1)
+	for d := 0; d < len(oldKeys); d++ {
+		if i := oldIdx + d; i < len(oldKeys) {
+			if idx, ok := newRows[oldKeys[i]]; ok {
+				newIdx = idx
+				break
+			}
+		}
+		if i := oldIdx - d; i >= 0 && d > 0 {
+			if idx, ok := newRows[oldKeys[i]]; ok {
+				newIdx = idx
+				break
+			}
+		}
+	}
+	list.SetCurrentItem(newIdx)
+	list.SetOffset(max(0, newIdx-(oldIdx-oldOffset)), 0)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const maxSavedHistory = 200
+
+// Directory for files that should persist across sessions, following
+// $XDG_STATE_HOME
+func stateDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	dir = filepath.Join(dir, "streamshower")
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func statePath(name string) (string, error) {
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Load the state saved by the last session. A file that fails to load only
+// loses its own part of the state
+func (ui *UI) loadState() error {
+	return errors.Join(
+		ui.watchQueue.load(),
+		ui.mainPage.viewerHistory.load(),
+		ui.loadCmdHistory(),
+		ui.loadRegisters(),
+	)
+}
+
+func (ui *UI) loadCmdHistory() error {
+	histPath, err := statePath("history")
+	if err != nil {
+		return err
+	}
+	histFile, err := os.Open(histPath)
+	if err == nil {
+		scanner := bufio.NewScanner(histFile)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				ui.cmdRegistry.history = append(ui.cmdRegistry.history, line)
+			}
+		}
+		ui.cmdRegistry.histIndex = len(ui.cmdRegistry.history)
+		_ = histFile.Close()
+		if err = scanner.Err(); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (ui *UI) loadRegisters() error {
+	regPath, err := statePath("registers.json")
+	if err != nil {
+		return err
+	}
+	regBytes, err := os.ReadFile(regPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	err = json.Unmarshal(regBytes, &ui.macroRegistry.registers)
+	if ui.macroRegistry.registers == nil {
+		ui.macroRegistry.registers = make(map[string]string)
+	}
+	return err
+}
+
+func (ui *UI) saveState() error {
+	if ui.mainPage.viewerhistory {
+		err := ui.mainPage.viewerHistory.save()
+		if err != nil {
+			return err
+		}
+	}
+
+	histPath, err := statePath("history")
+	if err != nil {
+		return err
+	}
+	history := ui.cmdRegistry.history
+	if len(history) > maxSavedHistory {
+		history = history[len(history)-maxSavedHistory:]
+	}
+	var histBuf strings.Builder
+	for _, line := range history {
+		histBuf.WriteString(line)
+		histBuf.WriteByte('\n')
+	}
+	err = os.WriteFile(histPath, []byte(histBuf.String()), 0o600)
+	if err != nil {
+		return err
+	}
+
+	regPath, err := statePath("registers.json")
+	if err != nil {
+		return err
+	}
+	regBytes, err := json.Marshal(ui.macroRegistry.registers)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(regPath, regBytes, 0o600)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	defaultStatusline  = "Status (%s)"
+	defaultFetchFormat = "%m (update in %ns) "
+)
+
+// Everything the statusline and fetch time view can show, gathered in one
+// place so both are rendered the same way
+type StatusModel struct {
+	Server       string
+	Twitch       int
+	TwitchShown  int
+	Strims       int
+	StrimsShown  int
+	LastModified time.Time
+	NextRefresh  time.Duration
+	Filter       string
+	Sort         string
+	Jobs         int
+	Error        string
+}
+
+func (ui *UI) statusModel() StatusModel {
+	m := ui.mainPage
+	model := StatusModel{
+		Server:      ui.addr.Redacted(),
+		Twitch:      m.streams.Twitch.Len(),
+		TwitchShown: countStreams(m.twitchFilter.indexMapping),
+		Strims:      m.streams.Strims.Len(),
+		StrimsShown: countStreams(m.strimsFilter.indexMapping),
+		Filter:      m.filterFor(m.focusedList).input,
+		Sort:        m.sortBy,
+		Jobs:        len(ui.jobRegistry.running()),
+		Error:       m.lastError,
+	}
+	if m.sortDesc && m.sortBy != "" {
+		model.Sort = "-" + m.sortBy
+	}
+	if ui.fetchMeta != nil {
+		model.LastModified = ui.fetchMeta.LastModified
+		model.NextRefresh = time.Until(ui.fetchMeta.LastModified.Add(ui.fetchMeta.RefreshInterval))
+	}
+	return model
+}
+
+// Number of streams in an index mapping, leaving out group headers
+func countStreams(mapping []int) int {
+	count := 0
+	for _, ix := range mapping {
+		if ix >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Expand the %-items of {format}, see `:help statusline-items`
+func (s StatusModel) format(format string) string {
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			out.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case '%':
+			out.WriteByte('%')
+		case 'e':
+			out.WriteString(tview.Escape(s.Error))
+		case 'f':
+			out.WriteString(tview.Escape(s.Filter))
+		case 'j':
+			out.WriteString(strconv.Itoa(s.Jobs))
+		case 'm':
+			if !s.LastModified.IsZero() {
+				out.WriteString(s.LastModified.In(time.Local).Format(time.TimeOnly))
+			}
+		case 'n':
+			if !s.LastModified.IsZero() {
+				out.WriteString(strconv.Itoa(int(s.NextRefresh.Round(time.Second).Seconds())))
+			}
+		case 'o':
+			out.WriteString(s.Sort)
+		case 'R':
+			out.WriteString(strconv.Itoa(s.StrimsShown))
+		case 'r':
+			out.WriteString(strconv.Itoa(s.Strims))
+		case 's':
+			out.WriteString(tview.Escape(s.Server))
+		case 'T':
+			out.WriteString(strconv.Itoa(s.TwitchShown))
+		case 't':
+			out.WriteString(strconv.Itoa(s.Twitch))
+		default:
+			out.WriteByte('%')
+			out.WriteByte(format[i])
+		}
+	}
+	return out.String()
+}
+
+// Render the statusline and fetch time view before every draw, sizing the
+// fetch time view to its text
+func (ui *UI) updateStatusViews(screen tcell.Screen) bool {
+	model := ui.statusModel()
+	ui.mainPage.appStatusText.SetTitle(model.format(ui.mainPage.statusline))
+	fetchText := "No timing data "
+	if !model.LastModified.IsZero() {
+		fetchText = model.format(ui.mainPage.fetchFormat)
+	}
+	ui.mainPage.fetchTimeView.SetText(fetchText)
+	ui.mainPage.commandRow.ResizeItem(ui.mainPage.fetchTimeView, tview.TaggedStringWidth(fetchText), 0)
+	return false
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Rows of a StreamList, rendered only when they come into view
+type ListSource interface {
+	RowCount() int
+	Rows(from, to int) []listRow
+}
+
+// A list that only holds the rows in view. The embedded tview.List is filled
+// with the visible window of {source} on every draw, so snapshots with
+// thousands of streams cost no more than the rows that fit on screen
+type StreamList struct {
+	*tview.List
+	source  ListSource
+	current int
+	offset  int
+	stale   bool // Rows changed since the changed func last ran
+	changed func(index int, mainText, secondaryText string, shortcut rune)
+
+	showSecondary bool
+	window        []listRow // Rows last given to the embedded list
+
+	table  *tview.Table // Draws the rows in the table list mode, nil otherwise
+	header []*tview.TableCell
+}
+
+func NewStreamList() *StreamList {
+	return &StreamList{
+		List:          tview.NewList(),
+		source:        emptySource{},
+		showSecondary: true,
+	}
+}
+
+type emptySource struct{}
+
+func (emptySource) RowCount() int               { return 0 }
+func (emptySource) Rows(from, to int) []listRow { return nil }
+
+func (l *StreamList) SetSource(source ListSource) *StreamList {
+	l.source = source
+	l.stale = true
+	return l
+}
+
+// Mark the rows as changed, the next SetCurrentItem runs the changed func
+// even when the index stays the same
+func (l *StreamList) Reload() {
+	l.stale = true
+}
+
+func (l *StreamList) GetItemCount() int {
+	return l.source.RowCount()
+}
+
+func (l *StreamList) GetItemText(index int) (string, string) {
+	if index < 0 || index >= l.source.RowCount() {
+		return "", ""
+	}
+	row := l.source.Rows(index, index+1)[0]
+	return row.main, row.secondary
+}
+
+func (l *StreamList) GetCurrentItem() int {
+	return l.current
+}
+
+// Select row {index}, clamped to the rows in the list like tview.List does
+func (l *StreamList) SetCurrentItem(index int) *StreamList {
+	count := l.source.RowCount()
+	index = max(0, min(index, count-1))
+	if (index != l.current || l.stale) && l.changed != nil && count > 0 {
+		mainText, secondaryText := l.GetItemText(index)
+		l.changed(index, mainText, secondaryText, 0)
+	}
+	l.current = index
+	l.stale = false
+	return l
+}
+
+func (l *StreamList) GetOffset() (int, int) {
+	_, horizontal := l.List.GetOffset()
+	return l.offset, horizontal
+}
+
+func (l *StreamList) SetOffset(items, horizontal int) *StreamList {
+	l.offset = items
+	l.List.SetOffset(0, horizontal)
+	return l
+}
+
+func (l *StreamList) SetChangedFunc(handler func(index int, mainText, secondaryText string, shortcut rune)) *StreamList {
+	l.changed = handler
+	return l
+}
+
+func (l *StreamList) ShowSecondaryText(show bool) *StreamList {
+	l.showSecondary = show
+	l.List.ShowSecondaryText(show)
+	return l
+}
+
+// Draw the rows as a tview.Table with {header} as its fixed first row, taking
+// the cells of each row from listRow.cells. A nil {header} goes back to the
+// list layout
+func (l *StreamList) SetColumns(header []*tview.TableCell) *StreamList {
+	l.header = header
+	if header == nil {
+		l.table = nil
+	} else if l.table == nil {
+		l.table = tview.NewTable().
+			SetFixed(1, 0).
+			SetSelectable(true, false)
+	}
+	return l
+}
+
+// Screen lines taken by one row
+func (l *StreamList) rowHeight() int {
+	if l.showSecondary {
+		return 2
+	}
+	return 1
+}
+
+// Screen position of the start of the current row
+func (l *StreamList) currentRowPosition() (int, int) {
+	x, y, _, _ := l.GetInnerRect()
+	if l.table != nil {
+		// Below the header of the table
+		y++
+	}
+	return x, y + (l.current-l.offset)*l.rowHeight()
+}
+
+// Screen lines the rows are drawn on, below the header of the table
+func (l *StreamList) rowsHeight() int {
+	_, _, _, height := l.GetInnerRect()
+	if l.table != nil {
+		return height - 1
+	}
+	return height
+}
+
+func (l *StreamList) Draw(screen tcell.Screen) {
+	height := l.rowsHeight()
+	count := l.source.RowCount()
+	l.current = max(0, min(l.current, count-1))
+
+	// Keep the current row in view, the same way tview.List scrolls
+	if l.current < l.offset {
+		l.offset = l.current
+	} else if l.showSecondary {
+		if 2*(l.current-l.offset) >= height-1 {
+			l.offset = (2*l.current + 3 - height) / 2
+		}
+	} else if l.current-l.offset >= height {
+		l.offset = l.current + 1 - height
+	}
+	l.offset = max(0, min(l.offset, count-1))
+
+	visible := (height + l.rowHeight() - 1) / l.rowHeight()
+	rows := l.source.Rows(l.offset, min(count, l.offset+visible))
+	if l.table != nil {
+		l.drawTable(screen, rows)
+		return
+	}
+	l.setWindow(rows)
+	l.List.SetCurrentItem(l.current - l.offset)
+	_, horizontal := l.List.GetOffset()
+	l.List.SetOffset(0, horizontal)
+	l.List.Draw(screen)
+}
+
+// Show {rows} in the embedded list, only touching the items that differ
+// from what is displayed. Items are matched by key, so scrolling or a stream
+// moving up removes and inserts the rows around the ones that stay instead of
+// rewriting them all
+func (l *StreamList) setWindow(rows []listRow) {
+	if l.List.GetItemCount() != len(l.window) {
+		// Changed behind our back, start over
+		l.List.Clear()
+		l.window = nil
+	}
+	oldIdx := make(map[string]int, len(l.window))
+	for i, row := range l.window {
+		if row.key != "" {
+			oldIdx[row.key] = i
+		}
+	}
+	// Items before i hold rows[:i], the items from i on hold l.window[old:]
+	old := 0
+	for i, row := range rows {
+		j, ok := oldIdx[row.key]
+		if !ok || row.key == "" || j < old {
+			l.List.InsertItem(i, row.main, row.secondary, 0, nil)
+			continue
+		}
+		for ; old < j; old++ {
+			l.List.RemoveItem(i)
+		}
+		old++
+		if prev := l.window[j]; prev.main != row.main || prev.secondary != row.secondary {
+			l.List.SetItemText(i, row.main, row.secondary)
+		}
+	}
+	for ; old < len(l.window); old++ {
+		l.List.RemoveItem(len(rows))
+	}
+	l.window = rows
+}
+
+// Draw the border and title of the list with {rows} in the table inside it
+func (l *StreamList) drawTable(screen tcell.Screen, rows []listRow) {
+	l.DrawForSubclass(screen, l)
+	l.table.Clear()
+	l.table.SetBackgroundColor(l.GetBackgroundColor())
+	for col, cell := range l.header {
+		l.table.SetCell(0, col, cell)
+	}
+	for i, row := range rows {
+		for col, text := range row.cells {
+			cell := tview.NewTableCell(text)
+			if col < len(l.header) {
+				cell.SetExpansion(l.header[col].Expansion)
+			}
+			l.table.SetCell(i+1, col, cell)
+		}
+	}
+	// Like the list, only show the selection while focused
+	l.table.SetSelectable(l.HasFocus(), false)
+	l.table.Select(l.current-l.offset+1, 0)
+	l.table.SetOffset(0, 0)
+	l.table.SetRect(l.GetInnerRect())
+	l.table.Draw(screen)
+}
+
+func (l *StreamList) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return l.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		if !l.InRect(event.Position()) {
+			return false, nil
+		}
+		switch action {
+		case tview.MouseLeftClick:
+			setFocus(l)
+			rectX, rectY, width, innerHeight := l.GetInnerRect()
+			x, y := event.Position()
+			if x >= rectX && x < rectX+width && y >= rectY && y < rectY+innerHeight {
+				if l.table != nil {
+					// Below the header of the table
+					y--
+				}
+				if index := l.offset + (y-rectY)/l.rowHeight(); y >= rectY && index < l.source.RowCount() {
+					l.SetCurrentItem(index)
+				}
+			}
+			consumed = true
+		case tview.MouseScrollUp:
+			if l.offset > 0 {
+				l.offset--
+			}
+			consumed = true
+		case tview.MouseScrollDown:
+			if (l.source.RowCount()-l.offset)*l.rowHeight() > l.rowsHeight() {
+				l.offset++
+			}
+			consumed = true
+		case tview.MouseScrollLeft, tview.MouseScrollRight:
+			if l.table != nil {
+				return true, nil
+			}
+			return l.List.MouseHandler()(action, event, setFocus)
+		}
+		return
+	})
+}
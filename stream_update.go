@@ -12,34 +12,32 @@ import (
 )
 
 func (ui *UI) streamUpdateLoop(ctx context.Context) {
-	setStatus := func(color string, text string) {
+	setStatus := func(group string, text string) {
 		ui.app.QueueUpdateDraw(func() {
-			ui.mainPage.appStatusText.SetText(fmt.Sprintf("[%s]%s[-]", color, text))
+			ui.mainPage.setStatus(group, text)
 		})
 	}
 	defer ui.wg.Done()
 
-	var err error
+	var (
+		err       error
+		fetchMeta *ResponseMetadata // Copy for this goroutine, ui.fetchMeta is set on the UI goroutine
+	)
 	fetchTimer := time.NewTimer(100 * time.Millisecond)
 	defer fetchTimer.Stop()
-	redrawTimer := time.NewTicker(time.Second)
-	defer redrawTimer.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ui.forceRemoteUpdateCh:
-			setStatus("orange", "Sending update...")
+			setStatus("StatusWarning", "Sending update...")
 			err = forceRemoteUpdate(ctx, ui.addr.String())
 			if errors.Is(err, context.Canceled) {
 				return
 			} else if err != nil {
-				setStatus("red", fmt.Sprintf("Error updating: %s", err))
+				setStatus("StatusError", fmt.Sprintf("Error updating: %s", err))
 			}
 			continue
-		case <-redrawTimer.C:
-			ui.app.QueueUpdateDraw(func() {})
-			continue
 		case <-ui.updateStreamsCh:
 			fetchTimer.Stop()
 			// pass
@@ -47,66 +45,81 @@ func (ui *UI) streamUpdateLoop(ctx context.Context) {
 			// pass
 		}
 
-		setStatus("orange", "Fetching streams...")
+		setStatus("StatusWarning", "Fetching streams...")
 		var (
 			streams *ls.Streams
 			meta    *ResponseMetadata
 		)
-		streams, meta, err = updateStreams(ctx, ui.fetchMeta, ui.addr.String())
+		streams, meta, err = updateStreams(ctx, fetchMeta, ui.addr.String())
 
 		var redirectErr *RedirectError
 		if errors.Is(err, context.Canceled) {
 			return
 		} else if errors.Is(err, ErrStreamsNotModified) {
-			ui.fetchMeta = meta
-			setStatus("green", fmt.Sprintf(
-				"No updates (%d Twitch streams and %d Strims streams)",
-				ui.mainPage.streams.Twitch.Len(),
-				ui.mainPage.streams.Strims.Len(),
-			))
-			nextUpdate := ui.fetchMeta.LastModified.Add(ui.fetchMeta.RefreshInterval)
+			fetchMeta = meta
+			ui.app.QueueUpdateDraw(func() {
+				ui.fetchMeta = meta
+				ui.redraw.wakeUp()
+				ui.mainPage.setStatus("StatusOk", fmt.Sprintf(
+					"No updates (%d Twitch streams and %d Strims streams)",
+					ui.mainPage.streams.Twitch.Len(),
+					ui.mainPage.streams.Strims.Len(),
+				))
+			})
+			nextUpdate := fetchMeta.LastModified.Add(fetchMeta.RefreshInterval)
 			fetchTimer.Reset(time.Until(nextUpdate))
 			continue
 		} else if errors.As(err, &redirectErr) {
 			var absoluteURL *url.URL
 			absoluteURL, err = url.Parse(redirectErr.Location)
 			if err != nil {
-				setStatus("red", "invalid redirect location")
+				setStatus("StatusError", "invalid redirect location")
 				continue
 			}
 			if absoluteURL.Scheme != "http" && absoluteURL.Scheme != "https" {
-				setStatus("red", "refusing to redirect to non-web scheme")
+				setStatus("StatusError", "refusing to redirect to non-web scheme")
 				continue
 			}
 			cmd := exec.Command("xdg-open", redirectErr.Location)
 			err = cmd.Start()
 			if err != nil {
-				setStatus("red", "Could not launch xdg-open")
+				setStatus("StatusError", "Could not launch xdg-open")
 				continue
 			}
-			setStatus("yellow", "run `:sync` to refresh after authenticating")
+			setStatus("StatusInfo", "run `:sync` to refresh after authenticating")
 			go func() {
 				_ = cmd.Wait()
 			}()
 			continue
 		} else if err != nil {
-			setStatus("red", fmt.Sprintf("Error fetching: %s", err))
+			setStatus("StatusError", fmt.Sprintf("Error fetching: %s", err))
 			fetchTimer.Reset(time.Minute)
 			continue
 		}
-		ui.mainPage.streams = streams
-		ui.fetchMeta = meta
-		nextUpdate := ui.fetchMeta.LastModified.Add(ui.fetchMeta.RefreshInterval)
+		fetchMeta = meta
+		nextUpdate := fetchMeta.LastModified.Add(fetchMeta.RefreshInterval)
 		fetchTimer.Reset(time.Until(nextUpdate))
 
-		ui.app.QueueUpdate(func() {
-			ui.mainPage.refreshTwitchList()
-			ui.mainPage.refreshStrimsList()
+		// The snapshot is only swapped on the UI goroutine, where the lists
+		// are drawn from it
+		ui.app.QueueUpdateDraw(func() {
+			previous := ui.mainPage.streams
+			ui.fetchMeta = meta
+			ui.mainPage.setStreams(streams)
+			// The countdown, uptimes and new marks are scheduled from the new
+			// snapshot
+			ui.redraw.wakeUp()
+			ui.mainPage.viewerHistory.record(streams, meta.LastModified)
+			changes := ui.mainPage.changeLog.record(previous, streams, time.Now())
+			status := fmt.Sprintf(
+				"Fetched %d Twitch streams and %d Strims streams",
+				streams.Twitch.Len(),
+				streams.Strims.Len(),
+			)
+			if summary := summarizeChanges(changes); summary != "" {
+				status += " (" + summary + ")"
+			}
+			ui.mainPage.setStatus("StatusOk", status)
 		})
-		setStatus("green", fmt.Sprintf(
-			"Fetched %d Twitch streams and %d Strims streams",
-			ui.mainPage.streams.Twitch.Len(),
-			ui.mainPage.streams.Strims.Len(),
-		))
 	}
 }
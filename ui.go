@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/url"
+	"regexp"
 	"sync"
+	"time"
 
 	ls "github.com/HoppenR/libstreams"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
@@ -16,11 +20,21 @@ type UI struct {
 	mainPage            *MainPage
 	cmdRegistry         *CommandRegistry
 	mapRegistry         *MappingRegistry
+	macroRegistry       *MacroRegistry
+	launcherRegistry    *LauncherRegistry
+	urlTemplates        map[OpenMethod]URLTemplateSource
+	jobRegistry         *JobRegistry
+	mpvIPC              *MpvIPC
+	watchQueue          *WatchQueue
+	redraw              *RedrawScheduler
 	updateStreamsCh     chan struct{}
 	forceRemoteUpdateCh chan struct{}
 	addr                *url.URL
 	wg                  sync.WaitGroup
 	mapDepth            int
+	foldPending         bool      // z was typed, waiting for the fold command
+	cmdlinePending      tcell.Key // <C-r> or <C-v> waiting for its key
+	count               int
 	fetchMeta           *ResponseMetadata
 }
 
@@ -33,24 +47,56 @@ type MainPage struct {
 	appStatusText *tview.TextView
 	fetchTimeView *tview.TextView
 	streamInfo    *tview.TextView
-	strimsList    *tview.List
-	twitchList    *tview.List
+	strimsList    *StreamList
+	twitchList    *StreamList
 
-	focusedList  *tview.List // Can either be strimsList or twitchList
+	focusedList  *StreamList // Can either be strimsList or twitchList
 	streams      *ls.Streams
 	twitchFilter *FilterInput
 	strimsFilter *FilterInput
 	lastSearch   string
 
+	twitchSelection *Selection
+	strimsSelection *Selection
+	highlights      map[string]Highlight
+	lastError       string
+	colorscheme     string
+	infoTemplates   InfoTemplates
+	viewerHistory   *ViewerHistory
+	changeLog       *ChangeLog
+
 	// :set options
-	strims  bool
-	winopen bool
+	clipboard      string
+	columns        []ListColumn
+	fetchFormat    string
+	groupBy        string
+	listMode       string
+	mpvipc         bool
+	newMark        time.Duration
+	playing        bool
+	redrawInterval time.Duration
+	sortBy         string
+	sortDesc       bool
+	statusline     string
+	strims         bool
+	viewerhistory  bool
+	winopen        bool
 }
 
 type FilterInput struct {
 	input        string
 	indexMapping []int
 	inverted     bool
+
+	// Cache of the compiled filter
+	compiledInput string
+	compiled      *regexp.Regexp
+	compileErr    error
+
+	// Set while the list is grouped by :set groupby
+	rowGroups  []string
+	groupStats map[string]GroupStats
+	folded     map[string]bool
 }
 
 func (ui *UI) SetAddress(rawAddr string) error {
@@ -74,30 +120,52 @@ func NewUI() *UI {
 	ui := &UI{
 		app: tview.NewApplication(),
 		mainPage: &MainPage{
-			appStatusText: tview.NewTextView(),
-			commandLine:   tview.NewInputField(),
-			commandRow:    tview.NewFlex(),
-			con:           tview.NewFlex(),
-			fetchTimeView: tview.NewTextView(),
-			infoCon:       tview.NewFlex(),
-			streamInfo:    tview.NewTextView(),
-			streamsCon:    tview.NewFlex(),
-			strimsList:    tview.NewList(),
-			twitchList:    tview.NewList(),
-			twitchFilter:  &FilterInput{},
-			strimsFilter:  &FilterInput{},
+			appStatusText:   tview.NewTextView(),
+			commandLine:     tview.NewInputField(),
+			commandRow:      tview.NewFlex(),
+			con:             tview.NewFlex(),
+			fetchTimeView:   tview.NewTextView(),
+			infoCon:         tview.NewFlex(),
+			streamInfo:      tview.NewTextView(),
+			streamsCon:      tview.NewFlex(),
+			strimsList:      NewStreamList(),
+			twitchList:      NewStreamList(),
+			twitchFilter:    &FilterInput{},
+			strimsFilter:    &FilterInput{},
+			viewerHistory:   NewViewerHistory(),
+			changeLog:       NewChangeLog(),
+			twitchSelection: NewSelection(),
+			strimsSelection: NewSelection(),
 			streams: &ls.Streams{
 				Twitch: new(ls.TwitchStreams),
 				Strims: new(ls.StrimsStreams),
 			},
-			strims: true,
+			highlights:     maps.Clone(darkHighlights),
+			colorscheme:    "dark",
+			clipboard:      "auto",
+			fetchFormat:    defaultFetchFormat,
+			listMode:       "list",
+			newMark:        defaultNewMark,
+			redrawInterval: defaultRedrawInterval,
+			statusline:     defaultStatusline,
+			strims:         true,
 		},
 		cmdRegistry:         NewCommandRegistry(),
 		mapRegistry:         NewMappingRegistry(),
+		macroRegistry:       NewMacroRegistry(),
+		launcherRegistry:    NewLauncherRegistry(),
+		urlTemplates:        NewURLTemplateRegistry(),
+		jobRegistry:         NewJobRegistry(),
+		mpvIPC:              NewMpvIPC(),
+		watchQueue:          NewWatchQueue(),
+		redraw:              NewRedrawScheduler(),
 		updateStreamsCh:     make(chan struct{}, 1),
 		forceRemoteUpdateCh: make(chan struct{}, 1),
 	}
 	ui.mainPage.focusedList = ui.mainPage.twitchList
+	ui.mainPage.twitchList.SetSource(twitchRows{ui.mainPage, ui.jobRegistry})
+	ui.mainPage.strimsList.SetSource(strimsRows{ui.mainPage, ui.jobRegistry})
+	ui.mainPage.columns, _ = parseColumns(defaultColumns)
 	return ui
 }
 
@@ -105,8 +173,24 @@ func (ui *UI) Run() error {
 	// Set title to "Streamshower"
 	fmt.Print("\033]2;Streamshower\a")
 
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	ui.app.SetScreen(&focusScreen{Screen: screen, onFocus: ui.redraw.setFocused})
+	screen.EnableFocus()
+
 	ui.setupMainPage()
 	ui.app.SetRoot(ui.mainPage.con, true)
+	if err := ui.loadState(); err != nil {
+		ui.mainPage.setStatus("StatusError", fmt.Sprintf("Could not load state: %s", err))
+	}
+	if err := ui.mainPage.loadInfoTemplates(); err != nil {
+		ui.mainPage.setStatus("StatusError", fmt.Sprintf("Could not load info templates: %s", err))
+	}
+	if err := ui.sourceRC(); err != nil {
+		ui.mainPage.setStatus("StatusError", err.Error())
+	}
 
 	// NOTE: These are in-order (LIFO) deferred calls
 	ctx, cancel := context.WithCancel(context.Background())
@@ -116,10 +200,12 @@ func (ui *UI) Run() error {
 	// Set up remote update checking
 	ui.wg.Add(1)
 	go ui.streamUpdateLoop(ctx)
+	ui.wg.Add(1)
+	go ui.redrawLoop(ctx)
 
 	if err := ui.app.Run(); err != nil {
 		return err
 	}
 
-	return nil
+	return ui.saveState()
 }
@@ -2,44 +2,112 @@ package main
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
 	"github.com/rivo/tview"
 )
 
 func (m *MainPage) refreshStrimsList() {
+	m.restoreStrimsList(m.saveCursor(m.strimsList))
+}
+
+// Rebuild the strims list and put the cursor back where it was saved
+func (m *MainPage) restoreStrimsList(cursor listCursor) {
 	if m.focusedList != m.strimsList {
 		m.strimsList.SetChangedFunc(nil)
 		defer m.strimsList.SetChangedFunc(m.updateStrimsStreamInfo)
 	}
-	oldIdx := m.strimsList.GetCurrentItem()
 	m.updateStrimsList(m.strimsFilter.input)
-	m.strimsList.SetCurrentItem(oldIdx)
+	m.restoreCursor(m.strimsList, cursor)
 }
 
 func (m *MainPage) updateStrimsList(filter string) {
-	m.strimsList.Clear()
 	m.strimsFilter.indexMapping = m.matchStrimsListIndex(filter)
+	m.strimsFilter.rowGroups = nil
+	if def := listColumns[m.groupBy]; def.strims != nil && m.strimsFilter.indexMapping != nil {
+		m.strimsFilter.groupRows(
+			m.strimsFilter.indexMapping,
+			func(ix int) string { return formatCell(def.strims(m, &m.streams.Strims.Data[ix])) },
+			func(ix int) int { return m.streams.Strims.Data[ix].Rustlers },
+		)
+	}
+	m.strimsList.Reload()
+}
+
+// Rows of the strims list, rendered from the index mapping as they are drawn
+type strimsRows struct {
+	m    *MainPage
+	jobs *JobRegistry // Marks the playing streams
+}
+
+func (r strimsRows) RowCount() int {
+	if r.m.strimsFilter.indexMapping == nil {
+		// An empty row that reports "No results"
+		return 1
+	}
+	return len(r.m.strimsFilter.indexMapping)
+}
+
+func (r strimsRows) Rows(from, to int) []listRow {
+	m := r.m
 	if m.strimsFilter.indexMapping == nil {
-		m.strimsList.AddItem("", "", 0, nil)
-		return
+		return []listRow{{}}
 	}
-	for _, v := range m.strimsFilter.indexMapping {
+	selected := m.selectedKeys(m.strimsList)
+	rows := make([]listRow, 0, to-from)
+	for row := from; row < to; row++ {
+		v := m.strimsFilter.indexMapping[row]
+		if v < 0 {
+			group := m.strimsFilter.rowGroups[row]
+			if m.listMode == "table" {
+				rows = append(rows, listRow{key: "group:" + group, cells: m.tableGroupRow(m.strimsFilter, group, hasStrimsColumn)})
+				continue
+			}
+			mainstr, secstr := m.groupHeader(m.strimsFilter, group)
+			rows = append(rows, listRow{key: "group:" + group, main: mainstr, secondary: secstr})
+			continue
+		}
+		if v >= len(m.streams.Strims.Data) {
+			// The mapping is behind the data, it is rebuilt on the next refresh
+			rows = append(rows, listRow{})
+			continue
+		}
 		stream := m.streams.Strims.Data[v]
-		mainstr := highlightSearch(stream.Channel, m.lastSearch)
-		secColor := "green"
+		if m.listMode == "table" {
+			marker := m.tableMarker(
+				selected[streamKey(&stream)],
+				m.changeLog.isNew(&stream, m.newMark),
+				m.playing && r.jobs.isPlaying(stream.Service, stream.Channel),
+			)
+			cells := m.tableRow(marker, func(def columnDef) (any, bool) {
+				if def.strims == nil {
+					return nil, false
+				}
+				return def.strims(m, &stream), true
+			})
+			rows = append(rows, listRow{key: streamKey(&stream), cells: cells})
+			continue
+		}
+		mainstr := m.hlText("StreamName", m.highlightSearch("StreamName", stream.Channel))
+		if m.playing && r.jobs.isPlaying(stream.Service, stream.Channel) {
+			mainstr = m.hlText("Playing", "▶") + " " + mainstr
+		}
+		if selected[streamKey(&stream)] {
+			mainstr = m.hlText("Marker", "*") + " " + mainstr
+		} else if m.changeLog.isNew(&stream, m.newMark) {
+			mainstr = m.hlText("NewStream", "+") + " " + mainstr
+		}
+		titleGroup := "StrimsTitle"
 		if stream.Nsfw {
-			secColor = "red"
+			titleGroup = "NsfwTitle"
 		}
 		secstr := fmt.Sprintf(
-			" %-6d[%s:-:u]%s[-:-:-]",
-			stream.Rustlers,
-			secColor,
-			tview.Escape(stream.Title),
+			" %s%s",
+			m.hlText("Viewers", fmt.Sprintf("%-6d", stream.Rustlers)),
+			m.hlText(titleGroup, tview.Escape(stream.Title)),
 		)
-		m.strimsList.AddItem(mainstr, secstr, 0, nil)
+		rows = append(rows, listRow{key: streamKey(&stream), main: mainstr, secondary: secstr})
 	}
+	return rows
 }
 
 func (m *MainPage) updateStrimsStreamInfo(tviewIx int, pri, sec string, _ rune) {
@@ -53,22 +121,26 @@ func (m *MainPage) updateStrimsStreamInfo(tviewIx int, pri, sec string, _ rune)
 		return
 	}
 	ix := m.strimsFilter.indexMapping[tviewIx]
+	if ix < 0 {
+		m.showGroupInfo(m.strimsFilter, m.strimsFilter.rowGroups[tviewIx])
+		return
+	}
 	stream := m.streams.Strims.Data[ix]
-	var title string
-	if stream.Service == "m3u8" {
-		title = stream.URL
+	m.renderStreamInfo("strims", stream.Channel, &stream)
+}
+
+// Focus {list}, showing the strims window if needed
+func (ui *UI) focusList(list *StreamList) {
+	if list == ui.mainPage.strimsList {
+		ui.enableStrimsList()
+	}
+	ui.app.SetFocus(list)
+	ui.mainPage.focusedList = list
+	if list == ui.mainPage.strimsList {
+		ui.mainPage.refreshStrimsList()
 	} else {
-		title = strings.ReplaceAll(stream.Title, "\n", " ")
+		ui.mainPage.refreshTwitchList()
 	}
-	title = removeVariationSelectors(title)
-	title = tview.Escape(title)
-	m.streamInfo.SetTitle(stream.Channel)
-	add(fmt.Sprintf("[red]Title[-]: %s\n", title))
-	add(fmt.Sprintf("[red]Rustlers[-]: %d [lightgray](%d afk)[-]\n", stream.Rustlers, stream.AfkRustlers))
-	add(fmt.Sprintf("[red]Service[-]: %s\n", stream.Service))
-	add(fmt.Sprintf("[red]Viewers[-]: %v\n", stream.Viewers))
-	add(fmt.Sprintf("[red]Live[-]: %v\n", stream.Live))
-	add(fmt.Sprintf("[red]AFK[-]: %v\n", stream.Afk))
 }
 
 func (ui *UI) toggleStrimsList() {
@@ -95,11 +167,12 @@ func (ui *UI) disableStrimsList() {
 
 func (m *MainPage) matchStrimsListIndex(filter string) []int {
 	var ixs []int
-	re, err := regexp.Compile(`(?i)` + filter)
+	re, err := m.strimsFilter.regexp(filter)
 	if err != nil {
 		for i := range m.streams.Strims.Data {
 			ixs = append(ixs, i)
 		}
+		m.sortStrimsIndex(ixs)
 		return ixs
 	}
 	for i, v := range m.streams.Strims.Data {
@@ -109,5 +182,6 @@ func (m *MainPage) matchStrimsListIndex(filter string) []int {
 			ixs = append(ixs, i)
 		}
 	}
+	m.sortStrimsIndex(ixs)
 	return ixs
 }
@@ -2,41 +2,108 @@ package main
 
 import (
 	"fmt"
-	"math"
-	"regexp"
-	"strings"
-	"time"
 
 	"github.com/rivo/tview"
 )
 
 func (m *MainPage) refreshTwitchList() {
+	m.restoreTwitchList(m.saveCursor(m.twitchList))
+}
+
+// Rebuild the twitch list and put the cursor back where it was saved
+func (m *MainPage) restoreTwitchList(cursor listCursor) {
 	if m.focusedList != m.twitchList {
 		m.twitchList.SetChangedFunc(nil)
 		defer m.twitchList.SetChangedFunc(m.updateTwitchStreamInfo)
 	}
-	oldIdx := m.twitchList.GetCurrentItem()
 	m.updateTwitchList(m.twitchFilter.input)
-	m.twitchList.SetCurrentItem(oldIdx)
+	m.restoreCursor(m.twitchList, cursor)
 }
 
 func (m *MainPage) updateTwitchList(filter string) {
-	m.twitchList.Clear()
 	m.twitchFilter.indexMapping = m.matchTwitchListIndex(filter)
+	m.twitchFilter.rowGroups = nil
+	if def := listColumns[m.groupBy]; def.twitch != nil && m.twitchFilter.indexMapping != nil {
+		m.twitchFilter.groupRows(
+			m.twitchFilter.indexMapping,
+			func(ix int) string { return formatCell(def.twitch(m, &m.streams.Twitch.Data[ix])) },
+			func(ix int) int { return m.streams.Twitch.Data[ix].ViewerCount },
+		)
+	}
+	m.twitchList.Reload()
+}
+
+// Rows of the twitch list, rendered from the index mapping as they are drawn
+type twitchRows struct {
+	m    *MainPage
+	jobs *JobRegistry // Marks the playing streams
+}
+
+func (r twitchRows) RowCount() int {
+	if r.m.twitchFilter.indexMapping == nil {
+		// An empty row that reports "No results"
+		return 1
+	}
+	return len(r.m.twitchFilter.indexMapping)
+}
+
+func (r twitchRows) Rows(from, to int) []listRow {
+	m := r.m
 	if m.twitchFilter.indexMapping == nil {
-		m.twitchList.AddItem("", "", 0, nil)
-		return
+		return []listRow{{}}
 	}
-	for _, v := range m.twitchFilter.indexMapping {
+	selected := m.selectedKeys(m.twitchList)
+	rows := make([]listRow, 0, to-from)
+	for row := from; row < to; row++ {
+		v := m.twitchFilter.indexMapping[row]
+		if v < 0 {
+			group := m.twitchFilter.rowGroups[row]
+			if m.listMode == "table" {
+				rows = append(rows, listRow{key: "group:" + group, cells: m.tableGroupRow(m.twitchFilter, group, hasTwitchColumn)})
+				continue
+			}
+			mainstr, secstr := m.groupHeader(m.twitchFilter, group)
+			rows = append(rows, listRow{key: "group:" + group, main: mainstr, secondary: secstr})
+			continue
+		}
+		if v >= len(m.streams.Twitch.Data) {
+			// The mapping is behind the data, it is rebuilt on the next refresh
+			rows = append(rows, listRow{})
+			continue
+		}
 		stream := m.streams.Twitch.Data[v]
-		mainstr := highlightSearch(stream.UserName, m.lastSearch)
+		if m.listMode == "table" {
+			marker := m.tableMarker(
+				selected[streamKey(&stream)],
+				m.changeLog.isNew(&stream, m.newMark),
+				m.playing && r.jobs.isPlaying(stream.GetService(), stream.UserName),
+			)
+			cells := m.tableRow(marker, func(def columnDef) (any, bool) {
+				if def.twitch == nil {
+					return nil, false
+				}
+				return def.twitch(m, &stream), true
+			})
+			rows = append(rows, listRow{key: streamKey(&stream), cells: cells})
+			continue
+		}
+		mainstr := m.hlText("StreamName", m.highlightSearch("StreamName", stream.UserName))
+		if m.playing && r.jobs.isPlaying(stream.GetService(), stream.UserName) {
+			mainstr = m.hlText("Playing", "▶") + " " + mainstr
+		}
+		if selected[streamKey(&stream)] {
+			mainstr = m.hlText("Marker", "*") + " " + mainstr
+		} else if m.changeLog.isNew(&stream, m.newMark) {
+			mainstr = m.hlText("NewStream", "+") + " " + mainstr
+		}
 		secstr := fmt.Sprintf(
-			" %-6d[green:-:u]%s[-:-:-]",
-			stream.ViewerCount,
-			tview.Escape(stream.GameName),
+			" %s%s",
+			m.hlText("Viewers", fmt.Sprintf("%-6d", stream.ViewerCount)),
+			m.hlText("GameName", tview.Escape(stream.GameName)),
 		)
-		m.twitchList.AddItem(mainstr, secstr, 0, nil)
+		rows = append(rows, listRow{key: streamKey(&stream), main: mainstr, secondary: secstr})
 	}
+	return rows
 }
 
 func (m *MainPage) updateTwitchStreamInfo(tviewIx int, pri, sec string, _ rune) {
@@ -50,37 +117,22 @@ func (m *MainPage) updateTwitchStreamInfo(tviewIx int, pri, sec string, _ rune)
 		return
 	}
 	ix := m.twitchFilter.indexMapping[tviewIx]
-	stream := m.streams.Twitch.Data[ix]
-	startLocal := stream.StartedAt.Local()
-	if stream.GameName == "" {
-		stream.GameName = "[::d]None[::-]"
+	if ix < 0 {
+		m.showGroupInfo(m.twitchFilter, m.twitchFilter.rowGroups[tviewIx])
+		return
 	}
-	title := strings.ReplaceAll(stream.Title, "\n", " ")
-	title = removeVariationSelectors(title)
-	title = tview.Escape(title)
-	m.streamInfo.SetTitle(stream.UserName)
-	add(fmt.Sprintf("[red]Title[-]: %s\n", title))
-	add(fmt.Sprintf("[red]Viewers[-]: %d\n", stream.ViewerCount))
-	add(fmt.Sprintf("[red]Game[-]: %s\n", stream.GameName))
-	add(fmt.Sprintf(
-		"[red]Started At[-]: %2.2d:%2.2d [lightgray](%.0fd %.0fh %0.fm ago)[-]\n",
-		startLocal.Hour(),
-		startLocal.Minute(),
-		math.Floor(time.Since(startLocal).Hours()/24),
-		math.Mod(math.Floor(time.Since(startLocal).Hours()), 24),
-		math.Mod(math.Floor(time.Since(startLocal).Minutes()), 60)),
-	)
-	add(fmt.Sprintf("[red]Language[-]: %s\n", stream.Language))
-	add(fmt.Sprintf("[red]Type[-]: %s\n", stream.Type))
+	stream := m.streams.Twitch.Data[ix]
+	m.renderStreamInfo("twitch", stream.UserName, &stream)
 }
 
 func (m *MainPage) matchTwitchListIndex(filter string) []int {
 	var ixs []int
-	re, err := regexp.Compile(`(?i)` + filter)
+	re, err := m.twitchFilter.regexp(filter)
 	if err != nil {
 		for i := range m.streams.Twitch.Data {
 			ixs = append(ixs, i)
 		}
+		m.sortTwitchIndex(ixs)
 		return ixs
 	}
 	for i, v := range m.streams.Twitch.Data {
@@ -90,5 +142,6 @@ func (m *MainPage) matchTwitchListIndex(filter string) []int {
 			ixs = append(ixs, i)
 		}
 	}
+	m.sortTwitchIndex(ixs)
 	return ixs
 }
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+)
+
+const (
+	viewerHistoryFile = "viewers.json"
+	maxViewerSamples  = 30
+	maxViewerAge      = 2 * time.Hour
+	viewerDeltaWindow = 10 * time.Minute
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+type ViewerSample struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// Viewer counts of each stream over the last fetches, keyed by streamKey
+type ViewerHistory struct {
+	samples map[string][]ViewerSample
+}
+
+func NewViewerHistory() *ViewerHistory {
+	return &ViewerHistory{samples: make(map[string][]ViewerSample)}
+}
+
+// Sparkline and change of viewers of a stream, sorting by growth
+type ViewerTrend struct {
+	spark string
+	delta int
+}
+
+func (h *ViewerHistory) add(key string, sample ViewerSample) {
+	samples := h.samples[key]
+	if len(samples) > 0 && !samples[len(samples)-1].Time.Before(sample.Time) {
+		return
+	}
+	samples = append(samples, sample)
+	if len(samples) > maxViewerSamples {
+		samples = samples[len(samples)-maxViewerSamples:]
+	}
+	h.samples[key] = samples
+}
+
+// Add a sample taken at {at} for every stream in {streams} and forget streams
+// that have not been seen for a while
+func (h *ViewerHistory) record(streams *ls.Streams, at time.Time) {
+	for i := range streams.Twitch.Data {
+		stream := &streams.Twitch.Data[i]
+		h.add(streamKey(stream), ViewerSample{Time: at, Count: stream.ViewerCount})
+	}
+	for i := range streams.Strims.Data {
+		stream := &streams.Strims.Data[i]
+		h.add(streamKey(stream), ViewerSample{Time: at, Count: stream.Rustlers})
+	}
+	h.prune(at)
+}
+
+func (h *ViewerHistory) prune(now time.Time) {
+	for key, samples := range h.samples {
+		if now.Sub(samples[len(samples)-1].Time) > maxViewerAge {
+			delete(h.samples, key)
+		}
+	}
+}
+
+func (h *ViewerHistory) trend(data ls.StreamData) ViewerTrend {
+	samples := h.samples[streamKey(data)]
+	trend := ViewerTrend{spark: sparkline(samples)}
+	if first, last, ok := deltaSamples(samples); ok {
+		trend.delta = last.Count - first.Count
+	}
+	return trend
+}
+
+func sparkline(samples []ViewerSample) string {
+	if len(samples) < 2 {
+		return ""
+	}
+	lo, hi := samples[0].Count, samples[0].Count
+	for _, s := range samples {
+		lo = min(lo, s.Count)
+		hi = max(hi, s.Count)
+	}
+	var spark strings.Builder
+	for _, s := range samples {
+		level := 0
+		if hi > lo {
+			level = (s.Count - lo) * (len(sparkBlocks) - 1) / (hi - lo)
+		}
+		spark.WriteRune(sparkBlocks[level])
+	}
+	return spark.String()
+}
+
+// The oldest sample within the delta window and the newest sample
+func deltaSamples(samples []ViewerSample) (ViewerSample, ViewerSample, bool) {
+	if len(samples) < 2 {
+		return ViewerSample{}, ViewerSample{}, false
+	}
+	last := samples[len(samples)-1]
+	for _, s := range samples[:len(samples)-1] {
+		if last.Time.Sub(s.Time) <= viewerDeltaWindow {
+			return s, last, true
+		}
+	}
+	// Fetches are further apart than the window
+	return samples[len(samples)-2], last, true
+}
+
+// Change of viewers over the delta window, such as "↑ 1.2k in 10 min"
+func (h *ViewerHistory) describeDelta(data ls.StreamData) string {
+	first, last, ok := deltaSamples(h.samples[streamKey(data)])
+	if !ok {
+		return ""
+	}
+	delta := last.Count - first.Count
+	arrow := "↑"
+	if delta < 0 {
+		arrow = "↓"
+		delta = -delta
+	} else if delta == 0 {
+		arrow = "="
+	}
+	return fmt.Sprintf("%s %s in %.0f min", arrow, formatCount(delta), last.Time.Sub(first.Time).Minutes())
+}
+
+// Short form of {n} such as 950, 1.2k or 3.4M
+func formatCount(n int) string {
+	switch {
+	// Counts that round up to 1000.0k are written as 1.0M
+	case n >= 999_950:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprint(n)
+}
+
+func (h *ViewerHistory) load() error {
+	path, err := statePath(viewerHistoryFile)
+	if err != nil {
+		return err
+	}
+	historyBytes, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	err = json.Unmarshal(historyBytes, &h.samples)
+	if h.samples == nil {
+		h.samples = make(map[string][]ViewerSample)
+	}
+	h.prune(time.Now())
+	return err
+}
+
+func (h *ViewerHistory) save() error {
+	path, err := statePath(viewerHistoryFile)
+	if err != nil {
+		return err
+	}
+	historyBytes, err := json.Marshal(h.samples)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, historyBytes, 0o600)
+}
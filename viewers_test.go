@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Samples with counts {counts} taken {step} apart
+func viewerSamples(step time.Duration, counts ...int) []ViewerSample {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := make([]ViewerSample, len(counts))
+	for i, count := range counts {
+		samples[i] = ViewerSample{Time: start.Add(time.Duration(i) * step), Count: count}
+	}
+	return samples
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   string
+	}{
+		{nil, ""},
+		{[]int{5}, ""},
+		{[]int{5, 5, 5}, "▁▁▁"},
+		{[]int{0, 7}, "▁█"},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+		{[]int{100, 50, 0}, "█▄▁"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(viewerSamples(time.Minute, tt.counts...)); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestDeltaSamples(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   []ViewerSample
+		ok        bool
+		wantFirst int
+		wantLast  int
+	}{
+		{"none", nil, false, 0, 0},
+		{"one", viewerSamples(time.Minute, 10), false, 0, 0},
+		{"within window", viewerSamples(5*time.Minute, 10, 20, 30), true, 10, 30},
+		// Samples 0 and 1 are 15 and 10 minutes old
+		{"older than window", viewerSamples(5*time.Minute, 10, 20, 30, 40), true, 20, 40},
+		{"fetches further apart", viewerSamples(time.Hour, 10, 20, 30), true, 20, 30},
+	}
+	for _, tt := range tests {
+		first, last, ok := deltaSamples(tt.samples)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && (first.Count != tt.wantFirst || last.Count != tt.wantLast) {
+			t.Errorf("%s: got %d..%d, want %d..%d", tt.name, first.Count, last.Count, tt.wantFirst, tt.wantLast)
+		}
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.0k"},
+		{1234, "1.2k"},
+		{56_789, "56.8k"},
+		{999_949, "999.9k"},
+		{999_950, "1.0M"},
+		{3_400_000, "3.4M"},
+	}
+	for _, tt := range tests {
+		if got := formatCount(tt.n); got != tt.want {
+			t.Errorf("formatCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	ls "github.com/HoppenR/libstreams"
+	"github.com/rivo/tview"
+)
+
+const (
+	watchLogFile    = "watched.log"
+	maxShownWatched = 200
+)
+
+// A stream opened through `:open`, stored as one JSON line in the watch log
+type WatchEntry struct {
+	Time    time.Time  `json:"time"`
+	Method  OpenMethod `json:"method"`
+	Service string     `json:"service"`
+	Name    string     `json:"name"`
+	Title   string     `json:"title"`
+	Game    string     `json:"game"`
+}
+
+func newWatchEntry(data ls.StreamData, method OpenMethod) WatchEntry {
+	fields := streamTemplateFields(data)
+	return WatchEntry{
+		Time:    time.Now(),
+		Method:  method,
+		Service: data.GetService(),
+		Name:    data.GetName(),
+		Title:   fields["Title"].(string),
+		Game:    fields["Game"].(string),
+	}
+}
+
+func appendWatchLog(entry WatchEntry) error {
+	path, err := statePath(watchLogFile)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return errors.Join(err, f.Close())
+}
+
+// The most recent entries of the watch log, newest first
+func readWatchLog() ([]WatchEntry, error) {
+	path, err := statePath(watchLogFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []WatchEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry WatchEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			// Skip lines that were cut off by a crash
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) > maxShownWatched {
+		entries = entries[len(entries)-maxShownWatched:]
+	}
+	slices.Reverse(entries)
+	return entries, scanner.Err()
+}
+
+// Entry {n} of the watch log, counting from 1 for the most recent open
+func watchLogEntry(n string) (WatchEntry, error) {
+	entries, err := readWatchLog()
+	if err != nil {
+		return WatchEntry{}, err
+	}
+	idx, err := strconv.Atoi(n)
+	if err != nil || idx < 1 || idx > len(entries) {
+		return WatchEntry{}, fmt.Errorf("invalid history entry: %s", n)
+	}
+	return entries[idx-1], nil
+}
+
+// Find the live stream {service}/{name} in the latest snapshot
+func (m *MainPage) findLiveStream(service, name string) (ls.StreamData, bool) {
+	if m.streams == nil {
+		return nil, false
+	}
+	key := service + "/" + name
+	for i := range m.streams.Twitch.Data {
+		if streamKey(&m.streams.Twitch.Data[i]) == key {
+			return &m.streams.Twitch.Data[i], true
+		}
+	}
+	for i := range m.streams.Strims.Data {
+		if streamKey(&m.streams.Strims.Data[i]) == key {
+			return &m.streams.Strims.Data[i], true
+		}
+	}
+	return nil, false
+}
+
+// The live stream {service}/{name}, or stream data rebuilt from what was
+// saved about it so that offline channels can still be opened
+func (m *MainPage) streamOrOffline(service, name, title, game string) ls.StreamData {
+	if data, ok := m.findLiveStream(service, name); ok {
+		return data
+	}
+	if service == "twitch" {
+		return &ls.TwitchStreamData{UserName: name, Title: title, GameName: game}
+	}
+	return &ls.StrimsStreamData{Channel: name, Service: service, Title: title}
+}
+
+func (ui *UI) describeWatchLog() ([]byte, error) {
+	entries, err := readWatchLog()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no streams opened yet")
+	}
+	var lines []byte
+	for i, entry := range entries {
+		liveGroup := "InfoDim"
+		if _, ok := ui.mainPage.findLiveStream(entry.Service, entry.Name); ok {
+			liveGroup = "StatusOk"
+		}
+		lines = fmt.Appendf(
+			lines,
+			"%s %s %-8s %s\n",
+			ui.mainPage.hlText("InfoLabel", fmt.Sprintf("%-3d", i+1)),
+			entry.Time.Format(time.DateTime),
+			entry.Method,
+			ui.mainPage.hlText(liveGroup, entry.Service+"/"+tview.Escape(entry.Name)),
+		)
+		if entry.Game != "" {
+			lines = fmt.Appendf(lines, "    %s\n", ui.mainPage.hlText("GameName", tview.Escape(entry.Game)))
+		}
+		if entry.Title != "" {
+			lines = fmt.Appendf(lines, "    %s\n", tview.Escape(entry.Title))
+		}
+	}
+	return lines, nil
+}
+
+// Focus the list containing the live stream of entry {n} and select it
+func (ui *UI) gotoWatchEntry(n string) error {
+	entry, err := watchLogEntry(n)
+	if err != nil {
+		return err
+	}
+	for _, list := range []*StreamList{ui.mainPage.twitchList, ui.mainPage.strimsList} {
+		for i := range len(ui.mainPage.filterFor(list).indexMapping) {
+			data := ui.mainPage.streamAt(list, i)
+			if data == nil || data.GetService() != entry.Service || data.GetName() != entry.Name {
+				continue
+			}
+			ui.focusList(list)
+			list.SetCurrentItem(i)
+			return nil
+		}
+	}
+	if _, ok := ui.mainPage.findLiveStream(entry.Service, entry.Name); ok {
+		return fmt.Errorf("%s is hidden by the current filter", entry.Name)
+	}
+	return fmt.Errorf("%s is not live", entry.Name)
+}
+
+func (ui *UI) historyCommand(args []string) error {
+	var info []byte
+	var title string
+	switch args[0] {
+	case "cmd":
+		if len(ui.cmdRegistry.history) == 0 {
+			return errors.New("no command history")
+		}
+		for i, line := range slices.Backward(ui.cmdRegistry.history) {
+			info = fmt.Appendf(info, "%s %s\n", ui.mainPage.hlText("InfoLabel", fmt.Sprintf("%-3d", i+1)), tview.Escape(line))
+		}
+		title = "COMMAND HISTORY"
+	case "streams":
+		var err error
+		info, err = ui.describeWatchLog()
+		if err != nil {
+			return err
+		}
+		title = "WATCH HISTORY"
+	case "open":
+		if len(args) != 2 {
+			return errors.New("usage: :history open {n}")
+		}
+		entry, err := watchLogEntry(args[1])
+		if err != nil {
+			return err
+		}
+		return ui.openStream(ui.mainPage.streamOrOffline(entry.Service, entry.Name, entry.Title, entry.Game), entry.Method)
+	case "goto":
+		if len(args) != 2 {
+			return errors.New("usage: :history goto {n}")
+		}
+		return ui.gotoWatchEntry(args[1])
+	default:
+		return fmt.Errorf("unknown history %s", args[0])
+	}
+	ui.mainPage.streamInfo.Clear()
+	ui.mainPage.streamInfo.ScrollTo(0, 0)
+	_, _ = ui.mainPage.streamInfo.Write(ui.mainPage.scrollHint())
+	_, _ = ui.mainPage.streamInfo.Write(info)
+	ui.mainPage.streamInfo.SetTitle(title)
+	return nil
+}